@@ -0,0 +1,246 @@
+// Package chart draws simple bar and line charts of message counts using nothing but the standard library
+package chart
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+)
+
+// Series is one user's counts, one for each label along the bottom of the chart
+type Series struct {
+	Name   string
+	Values []int
+}
+
+// Chart is a set of series plotted against the same labels
+type Chart struct {
+	Title  string
+	Labels []string
+	Series []Series
+	// Lines joins up each series' values instead of drawing them as bars grouped by label, for counts over time
+	Lines bool
+	// Width and Height are the size of the chart in pixels, 800x400 unless they're set otherwise
+	Width  int
+	Height int
+}
+
+// space around the plot itself for the title, legend and axis labels
+const (
+	marginLeft   = 50
+	marginRight  = 20
+	marginTop    = 60
+	marginBottom = 40
+)
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	ink        = color.RGBA{0x44, 0x44, 0x44, 0xff}
+	grid       = color.RGBA{0xdd, 0xdd, 0xdd, 0xff}
+	// one colour per series, picked to tell apart easily from each other, they go round again after the last one
+	palette = []color.RGBA{
+		{0xe6, 0x19, 0x4b, 0xff},
+		{0x43, 0x63, 0xd8, 0xff},
+		{0x3c, 0xb4, 0x4b, 0xff},
+		{0xf5, 0x82, 0x31, 0xff},
+		{0x91, 0x1e, 0xb4, 0xff},
+		{0x42, 0xd4, 0xf4, 0xff},
+		{0xf0, 0x32, 0xe6, 0xff},
+		{0x9a, 0x63, 0x24, 0xff},
+	}
+)
+
+type anchor int
+
+const (
+	anchorStart anchor = iota
+	anchorMiddle
+	anchorEnd
+)
+
+type point struct {
+	x, y float64
+}
+
+// canvas is something a chart can be drawn on, each output format provides its own
+type canvas interface {
+	rect(x, y, width, height float64, fill color.RGBA)
+	line(x1, y1, x2, y2 float64, stroke color.RGBA)
+	polyline(points []point, stroke color.RGBA)
+	// text is drawn with its baseline at y
+	text(x, y float64, s string, a anchor, fill color.RGBA)
+	textWidth(s string) float64
+}
+
+// drawing is anything that can lay itself out on a canvas
+type drawing interface {
+	size() (int, int)
+	draw(cv canvas)
+}
+
+// plot is the area inside the margins the data is drawn in
+type plot struct {
+	left, top, right, bottom float64
+	// max is the count at the very top of the y axis
+	max int
+}
+
+func (p plot) y(value int) float64 {
+	return p.bottom - float64(value)/float64(p.max)*(p.bottom-p.top)
+}
+
+func (c Chart) size() (int, int) {
+	width, height := c.Width, c.Height
+	if width <= 0 {
+		width = 800
+	}
+	if height <= 0 {
+		height = 400
+	}
+	return width, height
+}
+
+func colour(series int) color.RGBA {
+	return palette[series%len(palette)]
+}
+
+// blend mixes a fraction of the way from one colour to another
+func blend(from, to color.RGBA, fraction float64) color.RGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*fraction + 0.5)
+	}
+	return color.RGBA{mix(from.R, to.R), mix(from.G, to.G), mix(from.B, to.B), 0xff}
+}
+
+// draw lays the whole chart out on cv
+func (c Chart) draw(cv canvas) {
+	width, height := c.size()
+	cv.rect(0, 0, float64(width), float64(height), background)
+	cv.text(float64(width)/2, 24, c.Title, anchorMiddle, ink)
+	c.drawLegend(cv)
+
+	max, step := scale(c.max())
+	p := plot{
+		left:   marginLeft,
+		top:    marginTop,
+		right:  float64(width - marginRight),
+		bottom: float64(height - marginBottom),
+		max:    max,
+	}
+
+	// grid lines up the y axis, labelled with their count
+	for value := 0; value <= max; value += step {
+		cv.line(p.left, p.y(value), p.right, p.y(value), grid)
+		cv.text(p.left-6, p.y(value)+4, strconv.Itoa(value), anchorEnd, ink)
+	}
+
+	if c.Lines {
+		c.drawLines(cv, p)
+	} else {
+		c.drawBars(cv, p)
+	}
+
+	cv.line(p.left, p.bottom, p.right, p.bottom, ink)
+}
+
+// drawLegend puts a swatch of each series' colour and its name in a row under the title
+func (c Chart) drawLegend(cv canvas) {
+	x := float64(marginLeft)
+	for i, series := range c.Series {
+		cv.rect(x, 34, 10, 10, colour(i))
+		cv.text(x+14, 43, series.Name, anchorStart, ink)
+		x += 14 + cv.textWidth(series.Name) + 20
+	}
+}
+
+func (c Chart) drawBars(cv canvas, p plot) {
+	if len(c.Labels) == 0 || len(c.Series) == 0 {
+		return
+	}
+
+	// each label gets a slot along the x axis, the series' bars sit side by side in the middle 80% of it
+	slot := (p.right - p.left) / float64(len(c.Labels))
+	bar := slot * 0.8 / float64(len(c.Series))
+	every := c.labelEvery(cv, slot)
+
+	for i, label := range c.Labels {
+		x := p.left + float64(i)*slot + slot*0.1
+		for j, series := range c.Series {
+			if i >= len(series.Values) || series.Values[i] <= 0 {
+				continue
+			}
+			cv.rect(x+float64(j)*bar, p.y(series.Values[i]), bar, p.bottom-p.y(series.Values[i]), colour(j))
+		}
+		if i%every == 0 {
+			cv.text(p.left+(float64(i)+0.5)*slot, p.bottom+16, label, anchorMiddle, ink)
+		}
+	}
+}
+
+func (c Chart) drawLines(cv canvas, p plot) {
+	if len(c.Labels) == 0 {
+		return
+	}
+
+	step := (p.right - p.left) / math.Max(float64(len(c.Labels)-1), 1)
+	for j, series := range c.Series {
+		points := make([]point, 0, len(series.Values))
+		for i, value := range series.Values {
+			points = append(points, point{p.left + float64(i)*step, p.y(value)})
+		}
+		cv.polyline(points, colour(j))
+	}
+
+	every := c.labelEvery(cv, step)
+	for i, label := range c.Labels {
+		if i%every == 0 {
+			cv.text(p.left+float64(i)*step, p.bottom+16, label, anchorMiddle, ink)
+		}
+	}
+}
+
+// labelEvery works out how many labels to skip along the x axis so the ones left don't run into each other
+func (c Chart) labelEvery(cv canvas, spacing float64) int {
+	widest := 0.0
+	for _, label := range c.Labels {
+		widest = math.Max(widest, cv.textWidth(label))
+	}
+	every := int(math.Ceil((widest + 10) / spacing))
+	if every < 1 {
+		every = 1
+	}
+	return every
+}
+
+// max returns the biggest count in any series
+func (c Chart) max() int {
+	max := 0
+	for _, series := range c.Series {
+		for _, value := range series.Values {
+			if value > max {
+				max = value
+			}
+		}
+	}
+	return max
+}
+
+// scale rounds max up to a round number for the top of the y axis, and picks a round step to mark the way up to it
+func scale(max int) (int, int) {
+	if max < 1 {
+		max = 1
+	}
+
+	step := 1
+	for step*10 <= max {
+		step *= 10
+	}
+	if max/step >= 5 {
+		step *= 2
+	}
+	if max/step < 2 && step > 1 {
+		step /= 2
+	}
+
+	return (max + step - 1) / step * step, step
+}
@@ -0,0 +1,167 @@
+package chart
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Heatmap shades a grid of counts, one grid for each series, eg. hour of the day across by day of the week down
+type Heatmap struct {
+	Title string
+	// Rows label the grid from top to bottom, Columns from left to right
+	Rows    []string
+	Columns []string
+	// Series each hold a grid of counts, Values[row][column]
+	Series []HeatmapSeries
+	// Width is the width of the picture in pixels, 800 unless it's set otherwise. Its height is however much the
+	// series need.
+	Width int
+}
+
+// HeatmapSeries is one user's grid of counts
+type HeatmapSeries struct {
+	Name   string
+	Values [][]int
+}
+
+// the layout of each series' grid
+const (
+	heatmapCellHeight = 18
+	heatmapNameHeight = 20
+	heatmapGap        = 30
+)
+
+func (h Heatmap) size() (int, int) {
+	width := h.Width
+	if width <= 0 {
+		width = 800
+	}
+	return width, marginTop + len(h.Series)*h.panelHeight()
+}
+
+func (h Heatmap) panelHeight() int {
+	return heatmapNameHeight + len(h.Rows)*heatmapCellHeight + heatmapGap
+}
+
+// max returns the biggest count in one series' grid
+func (s HeatmapSeries) max() int {
+	max := 0
+	for _, row := range s.Values {
+		for _, value := range row {
+			if value > max {
+				max = value
+			}
+		}
+	}
+	return max
+}
+
+func (s HeatmapSeries) value(row, column int) int {
+	if row < len(s.Values) && column < len(s.Values[row]) {
+		return s.Values[row][column]
+	}
+	return 0
+}
+
+// draw shades every cell between the background and the series' colour. Each series is shaded against its own
+// busiest cell, so a quiet user's habits show up as clearly as a chatty one's.
+func (h Heatmap) draw(cv canvas) {
+	width, height := h.size()
+	cv.rect(0, 0, float64(width), float64(height), background)
+	cv.text(float64(width)/2, 24, h.Title, anchorMiddle, ink)
+	if len(h.Columns) == 0 {
+		return
+	}
+
+	cell := float64(width-marginLeft-marginRight) / float64(len(h.Columns))
+	every := h.labelEvery(cv, cell)
+
+	for i, series := range h.Series {
+		top := float64(marginTop - heatmapNameHeight + i*h.panelHeight())
+		cv.text(marginLeft, top+12, series.Name, anchorStart, ink)
+		top += heatmapNameHeight
+
+		max := series.max()
+		for row, label := range h.Rows {
+			y := top + float64(row*heatmapCellHeight)
+			cv.text(marginLeft-6, y+heatmapCellHeight/2+4, label, anchorEnd, ink)
+			for column := range h.Columns {
+				fill := grid
+				if value := series.value(row, column); value > 0 {
+					fill = blend(background, colour(i), 0.1+0.9*float64(value)/float64(max))
+				}
+				// a pixel's gap between cells keeps them apart
+				cv.rect(marginLeft+float64(column)*cell, y, cell-1, heatmapCellHeight-1, fill)
+			}
+		}
+
+		bottom := top + float64(len(h.Rows)*heatmapCellHeight)
+		for column, label := range h.Columns {
+			if column%every == 0 {
+				cv.text(marginLeft+(float64(column)+0.5)*cell, bottom+14, label, anchorMiddle, ink)
+			}
+		}
+	}
+}
+
+func (h Heatmap) labelEvery(cv canvas, spacing float64) int {
+	return Chart{Labels: h.Columns}.labelEvery(cv, spacing)
+}
+
+// WriteSVG writes the heatmap as a standalone SVG image
+func (h Heatmap) WriteSVG(w io.Writer) error {
+	return writeSVG(w, h)
+}
+
+// WritePNG writes the heatmap as a PNG image, with the same limits on its labels as Chart.WritePNG
+func (h Heatmap) WritePNG(w io.Writer) error {
+	return writePNG(w, h)
+}
+
+var (
+	unicodeShades = []rune(" ░▒▓█")
+	asciiShades   = []rune(" .:*#")
+)
+
+// WriteText draws the heatmap for a terminal, each cell shaded with a character two columns wide
+func (h Heatmap) WriteText(w io.Writer, style TextStyle) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "%s\n", h.Title)
+
+	shades := asciiShades
+	if style.Unicode {
+		shades = unicodeShades
+	}
+	label_width := widest(h.Rows)
+
+	for i, series := range h.Series {
+		fmt.Fprintf(b, "%s\n", series.Name)
+
+		max := series.max()
+		for row, label := range h.Rows {
+			cells := make([]string, 0, len(h.Columns))
+			for column := range h.Columns {
+				shade := 0
+				if value := series.value(row, column); value > 0 {
+					// like the sparklines, anything at all gets at least the lightest shade
+					shade = 1 + value*(len(shades)-2)/max
+				}
+				cells = append(cells, strings.Repeat(string(shades[shade]), 2))
+			}
+			fmt.Fprintf(b, "%s %s\n", pad(label, label_width), style.paint(i, strings.Join(cells, "")))
+		}
+
+		// a label over every third column, there's no room for more
+		axis := ""
+		for column, label := range h.Columns {
+			if column%3 == 0 {
+				axis += pad(label, 6)
+			}
+		}
+		fmt.Fprintf(b, "%s %s\n\n", pad("", label_width), strings.TrimRight(axis, " "))
+	}
+
+	return b.Flush()
+}
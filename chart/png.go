@@ -0,0 +1,107 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+)
+
+// pngCanvas draws straight onto an image, text is written in the built in bitmap font blown up by scale
+type pngCanvas struct {
+	img   *image.RGBA
+	scale int
+}
+
+func (p *pngCanvas) rect(x, y, width, height float64, fill color.RGBA) {
+	r := image.Rect(round(x), round(y), round(x+width), round(y+height))
+	draw.Draw(p.img, r, image.NewUniform(fill), image.Point{}, draw.Src)
+}
+
+func (p *pngCanvas) line(x1, y1, x2, y2 float64, stroke color.RGBA) {
+	p.stroke(x1, y1, x2, y2, 1, stroke)
+}
+
+func (p *pngCanvas) polyline(points []point, stroke color.RGBA) {
+	for i := 1; i < len(points); i++ {
+		p.stroke(points[i-1].x, points[i-1].y, points[i].x, points[i].y, 2, stroke)
+	}
+}
+
+// stroke draws a line width pixels thick by stamping a square every half a pixel along it
+func (p *pngCanvas) stroke(x1, y1, x2, y2 float64, width int, c color.RGBA) {
+	steps := int(math.Ceil(math.Max(math.Abs(x2-x1), math.Abs(y2-y1))*2)) + 1
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x := int(math.Floor(x1 + (x2-x1)*t))
+		y := int(math.Floor(y1 + (y2-y1)*t))
+		for dx := 0; dx < width; dx++ {
+			for dy := 0; dy < width; dy++ {
+				p.img.SetRGBA(x+dx, y+dy, c)
+			}
+		}
+	}
+}
+
+func (p *pngCanvas) text(x, y float64, text string, a anchor, fill color.RGBA) {
+	switch a {
+	case anchorMiddle:
+		x -= p.textWidth(text) / 2
+	case anchorEnd:
+		x -= p.textWidth(text)
+	}
+
+	// every pixel of every glyph is the same colour, so they can all share one source image
+	paint := image.NewUniform(fill)
+	left, top := round(x), round(y)-glyphHeight*p.scale
+	for _, r := range text {
+		g := glyph(r)
+		for row := 0; row < glyphHeight; row++ {
+			for column := 0; column < glyphWidth; column++ {
+				if g[row]&(1<<uint(glyphWidth-1-column)) == 0 {
+					continue
+				}
+				pixel := image.Rect(left+column*p.scale, top+row*p.scale, left+(column+1)*p.scale, top+(row+1)*p.scale)
+				draw.Draw(p.img, pixel, paint, image.Point{}, draw.Src)
+			}
+		}
+		left += (glyphWidth + 1) * p.scale
+	}
+}
+
+func (p *pngCanvas) textWidth(text string) float64 {
+	runes := len([]rune(text))
+	if runes == 0 {
+		return 0
+	}
+	return float64((runes*(glyphWidth+1) - 1) * p.scale)
+}
+
+func round(f float64) int {
+	return int(math.Floor(f + 0.5))
+}
+
+// WritePNG writes the chart as a PNG image. Only ASCII can be written in its labels, anything else comes out as
+// question marks.
+func (c Chart) WritePNG(w io.Writer) error {
+	return writePNG(w, c)
+}
+
+func writePNG(w io.Writer, d drawing) error {
+	width, height := d.size()
+
+	// the bitmap font is tiny, so it grows along with the chart
+	scale := width / 800
+	if height/400 < scale {
+		scale = height / 400
+	}
+	if scale < 1 {
+		scale = 1
+	}
+
+	p := &pngCanvas{img: image.NewRGBA(image.Rect(0, 0, width, height)), scale: scale}
+	d.draw(p)
+	return png.Encode(w, p.img)
+}
@@ -0,0 +1,67 @@
+package chart
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"unicode/utf8"
+)
+
+// svgCanvas collects SVG elements in a buffer, so nothing is written out until the whole chart has been drawn
+type svgCanvas struct {
+	buf bytes.Buffer
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (s *svgCanvas) rect(x, y, width, height float64, fill color.RGBA) {
+	fmt.Fprintf(&s.buf, "<rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"%s\"/>\n", x, y, width, height, hex(fill))
+}
+
+func (s *svgCanvas) line(x1, y1, x2, y2 float64, stroke color.RGBA) {
+	fmt.Fprintf(&s.buf, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"%s\"/>\n", x1, y1, x2, y2, hex(stroke))
+}
+
+func (s *svgCanvas) polyline(points []point, stroke color.RGBA) {
+	s.buf.WriteString("<polyline points=\"")
+	for i, p := range points {
+		if i > 0 {
+			s.buf.WriteString(" ")
+		}
+		fmt.Fprintf(&s.buf, "%.1f,%.1f", p.x, p.y)
+	}
+	fmt.Fprintf(&s.buf, "\" fill=\"none\" stroke=\"%s\" stroke-width=\"1.5\" stroke-linejoin=\"round\"/>\n", hex(stroke))
+}
+
+func (s *svgCanvas) text(x, y float64, text string, a anchor, fill color.RGBA) {
+	anchors := map[anchor]string{anchorStart: "start", anchorMiddle: "middle", anchorEnd: "end"}
+	fmt.Fprintf(&s.buf, "<text x=\"%.1f\" y=\"%.1f\" text-anchor=\"%s\" fill=\"%s\">", x, y, anchors[a], hex(fill))
+	xml.EscapeText(&s.buf, []byte(text))
+	s.buf.WriteString("</text>\n")
+}
+
+// textWidth guesses, since there's no way to know what font the SVG will end up being shown in
+func (s *svgCanvas) textWidth(text string) float64 {
+	return float64(utf8.RuneCountInString(text)) * 7
+}
+
+// WriteSVG writes the chart as a standalone SVG image
+func (c Chart) WriteSVG(w io.Writer) error {
+	return writeSVG(w, c)
+}
+
+func writeSVG(w io.Writer, d drawing) error {
+	width, height := d.size()
+
+	s := new(svgCanvas)
+	fmt.Fprintf(&s.buf, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"Helvetica, Arial, sans-serif\" font-size=\"12\">\n", width, height, width, height)
+	d.draw(s)
+	s.buf.WriteString("</svg>\n")
+
+	_, err := s.buf.WriteTo(w)
+	return err
+}
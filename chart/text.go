@@ -0,0 +1,183 @@
+package chart
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// TextStyle says what a terminal can show
+type TextStyle struct {
+	// Width is how many columns there are to draw in, 80 unless it's set otherwise
+	Width int
+	// Unicode draws with block characters, without it charts are plain ASCII
+	Unicode bool
+	// Colour gives each series its own colour with ANSI escape codes
+	Colour bool
+}
+
+// the ANSI colours closest to the palette
+var ansiColours = []string{"31", "34", "32", "33", "35", "36", "95", "93"}
+
+var (
+	// eighths of a block, for the ragged end of a bar
+	unicodeBar   = []rune(" ▏▎▍▌▋▊▉█")
+	unicodeSpark = []rune(" ▁▂▃▄▅▆▇█")
+	asciiSpark   = []rune(" .:-=+*#%@")
+)
+
+func (s TextStyle) paint(series int, text string) string {
+	if !s.Colour {
+		return text
+	}
+	return fmt.Sprintf("\x1b[%sm%s\x1b[0m", ansiColours[series%len(ansiColours)], text)
+}
+
+func (s TextStyle) width() int {
+	if s.Width <= 0 {
+		return 80
+	}
+	return s.Width
+}
+
+// WriteText draws the chart for a terminal. Bar charts get a bar for every series under each label, line charts
+// become a sparkline for each series.
+func (c Chart) WriteText(w io.Writer, style TextStyle) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "%s\n", c.Title)
+	if c.Lines {
+		c.writeSparklines(b, style)
+	} else {
+		c.writeBars(b, style)
+	}
+	fmt.Fprintln(b)
+	return b.Flush()
+}
+
+// widest returns the length of the longest of names
+func widest(names []string) int {
+	width := 0
+	for _, name := range names {
+		if n := utf8.RuneCountInString(name); n > width {
+			width = n
+		}
+	}
+	return width
+}
+
+// pad fills s out with spaces to width columns
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func (c Chart) names() []string {
+	names := make([]string, 0, len(c.Series))
+	for _, series := range c.Series {
+		names = append(names, series.Name)
+	}
+	return names
+}
+
+func (c Chart) writeBars(w io.Writer, style TextStyle) {
+	label_width := widest(c.Labels)
+	name_width := widest(c.names())
+	max := c.max()
+	count_width := len(fmt.Sprint(max))
+
+	// whatever's left of the line once the label, name and count are written is for the bar
+	bar_width := style.width() - label_width - name_width - count_width - 3
+	if bar_width < 10 {
+		bar_width = 10
+	}
+
+	for i, label := range c.Labels {
+		for j, series := range c.Series {
+			value := 0
+			if i < len(series.Values) {
+				value = series.Values[i]
+			}
+
+			if j > 0 {
+				label = ""
+			}
+			bar := style.bar(value, max, bar_width)
+			fmt.Fprintf(w, "%s %s %s %*d\n", pad(label, label_width), pad(series.Name, name_width), style.paint(j, bar), count_width, value)
+		}
+	}
+}
+
+// bar draws value as a bar out of width columns, where a full width bar is max
+func (s TextStyle) bar(value int, max int, width int) string {
+	if max == 0 {
+		return strings.Repeat(" ", width)
+	}
+
+	if !s.Unicode {
+		filled := (value*width + max/2) / max
+		return strings.Repeat("#", filled) + strings.Repeat(" ", width-filled)
+	}
+
+	eighths := (value*width*8 + max/2) / max
+	bar := strings.Repeat(string(unicodeBar[8]), eighths/8)
+	if eighths%8 > 0 {
+		bar += string(unicodeBar[eighths%8])
+		return bar + strings.Repeat(" ", width-eighths/8-1)
+	}
+	return bar + strings.Repeat(" ", width-eighths/8)
+}
+
+func (c Chart) writeSparklines(w io.Writer, style TextStyle) {
+	name_width := widest(c.names())
+	columns := style.width() - name_width - 1
+	if columns < 10 {
+		columns = 10
+	}
+
+	// when there are more points than columns, neighbouring points are added together to fit
+	points := len(c.Labels)
+	per_column := (points + columns - 1) / columns
+	if per_column < 1 {
+		per_column = 1
+	}
+
+	squashed := make([][]int, len(c.Series))
+	max := 0
+	for j, series := range c.Series {
+		for i, value := range series.Values {
+			if i%per_column == 0 {
+				squashed[j] = append(squashed[j], 0)
+			}
+			squashed[j][i/per_column] += value
+			if squashed[j][i/per_column] > max {
+				max = squashed[j][i/per_column]
+			}
+		}
+	}
+
+	ticks := asciiSpark
+	if style.Unicode {
+		ticks = unicodeSpark
+	}
+
+	for j, series := range c.Series {
+		line := make([]rune, 0, len(squashed[j]))
+		for _, value := range squashed[j] {
+			tick := 0
+			if value > 0 {
+				// anything at all gets at least the lowest tick, so it can't be mistaken for nothing
+				tick = 1 + value*(len(ticks)-2)/max
+			}
+			line = append(line, ticks[tick])
+		}
+		fmt.Fprintf(w, "%s %s\n", pad(series.Name, name_width), style.paint(j, string(line)))
+	}
+
+	if len(c.Labels) > 0 {
+		fmt.Fprintf(w, "%s %s to %s\n", pad("", name_width), c.Labels[0], c.Labels[len(c.Labels)-1])
+	}
+}
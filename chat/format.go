@@ -0,0 +1,230 @@
+// Package chat reads exported chat logs, in whichever format they come in, into a stream of Messages
+package chat
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// how much of the input we look at when guessing what format it's in
+const sniffSize = 4096
+
+// the format we assume when nothing else recognises the input, it's the one kissyface was written for
+const defaultFormat = "telegram-text"
+
+// Diagnostic describes a piece of the input a Parser couldn't turn into a Message
+type Diagnostic struct {
+	// Line is the line (or record) number in the input, or 0 when the format has no such thing
+	Line   int
+	Text   string
+	Reason string
+}
+
+// checkTimestamp makes sure a date and time picked out of the input is a real moment, time.Date would otherwise quietly
+// turn the 31st of February into March
+func checkTimestamp(year int, month int, day int, hour int, minute int, second int) error {
+	if month < 1 || month > 12 {
+		return errors.New(fmt.Sprintf("month %d out of range", month))
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if day < 1 || date.Day() != day {
+		return errors.New(fmt.Sprintf("day %d out of range for %s %d", day, time.Month(month), year))
+	}
+
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 || second < 0 || second > 59 {
+		return errors.New(fmt.Sprintf("time %02d:%02d:%02d out of range", hour, minute, second))
+	}
+
+	return nil
+}
+
+func (d Diagnostic) String() string {
+	if d.Line > 0 {
+		return fmt.Sprintf("line %d (%s): %s", d.Line, d.Reason, d.Text)
+	}
+	return fmt.Sprintf("(%s): %s", d.Reason, d.Text)
+}
+
+// Parser reads a single input format, a new Parser is made for every input so it can carry state between pages.
+// Timestamps which don't say what time zone they're in are read in the zone the Parser was made with.
+type Parser interface {
+	// Parse hands every message found in r to emit, and anything it had to skip over to warn
+	Parse(r io.Reader, emit func(Message), warn func(Diagnostic)) error
+}
+
+// Namer is implemented by Parsers for formats which record the name of the chat, it's only meaningful once parsing
+// has finished
+type Namer interface {
+	ChatName() string
+}
+
+// Chat describes where a stream of messages came from
+type Chat struct {
+	// Name is the name of the chat as recorded in the export, or empty if the format doesn't record one
+	Name string
+	// Format is the name of the format the chat was read as
+	Format string
+	// Inputs are the files the messages were read from, in order
+	Inputs []string
+}
+
+// Format is an input format kissyface knows how to read
+type Format struct {
+	// Name is what the user passes to --format to select this format
+	Name string
+	// Priority orders the formats Detect tries, lowest first. The formats that are surest of themselves go first, so
+	// a looser check never gets the chance to claim an input meant for them.
+	Priority int
+	// Detect reports whether the first few KB of an input look like this format
+	Detect func(head []byte) bool
+	// Inputs expands the path given on the command line into the files to parse, in order. It may be nil, in
+	// which case the path is parsed as it is.
+	Inputs func(path string) ([]string, error)
+	// New makes a Parser for a single input
+	New func(location *time.Location) Parser
+}
+
+var formats = make(map[string]Format)
+
+// Register makes a format available to Lookup and Detect, the formats in this package register themselves from init()
+func Register(format Format) {
+	if _, present := formats[format.Name]; present {
+		panic(fmt.Sprintf("input format %s registered twice", format.Name))
+	}
+	formats[format.Name] = format
+}
+
+// Formats lists the name of every registered format in a stable order
+func Formats() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (f Format) inputs(path string) ([]string, error) {
+	if f.Inputs == nil {
+		return []string{path}, nil
+	}
+	return f.Inputs(path)
+}
+
+// sniff reads the start of a file for Detect to look at
+func sniff(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// detectOrder returns every format in the order Detect tries them, by Priority and then by name
+func detectOrder() []Format {
+	order := make([]Format, 0, len(formats))
+	for _, name := range Formats() {
+		order = append(order, formats[name])
+	}
+	sort.SliceStable(order, func(i, j int) bool { return order[i].Priority < order[j].Priority })
+	return order
+}
+
+// Lookup returns the format registered under name
+func Lookup(name string) (Format, error) {
+	format, present := formats[name]
+	if !present {
+		return Format{}, errors.New(fmt.Sprintf("Unknown format %s, expected one of: %s", name, strings.Join(Formats(), ", ")))
+	}
+	return format, nil
+}
+
+// Detect returns the first format which recognises the file (or directory) at path. Files nothing recognises are
+// assumed to be in the plain text format kissyface was written for.
+func Detect(path string) (Format, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Format{}, err
+	}
+
+	for _, format := range detectOrder() {
+
+		// directories only make sense to formats which know how to find their files in one
+		if info.IsDir() && format.Inputs == nil {
+			continue
+		}
+
+		inputs, err := format.inputs(path)
+		if err != nil || len(inputs) == 0 {
+			continue
+		}
+
+		head, err := sniff(inputs[0])
+		if err != nil {
+			return Format{}, errors.Wrap(err, fmt.Sprintf("Unable to access file: %s", inputs[0]))
+		}
+
+		if format.Detect(head) {
+			return format, nil
+		}
+	}
+
+	if info.IsDir() {
+		return Format{}, errors.New(fmt.Sprintf("Unable to find a chat export in %s", path))
+	}
+
+	return formats[defaultFormat], nil
+}
+
+// ParseFile reads every message out of path (and any pages which go with it), numbering them in the order they were
+// found. Timestamps which don't carry their own time zone are read in location.
+func (f Format) ParseFile(path string, location *time.Location, emit func(Message), warn func(Diagnostic)) (Chat, error) {
+	inputs, err := f.inputs(path)
+	if err != nil {
+		return Chat{}, errors.Wrap(err, fmt.Sprintf("Unable to find %s input in %s", f.Name, path))
+	}
+
+	chat := Chat{Format: f.Name, Inputs: inputs}
+
+	parser := f.New(location)
+
+	index := 0
+	number := func(m Message) {
+		m.Index = index
+		index++
+		emit(m)
+	}
+
+	for _, input := range inputs {
+		file, err := os.Open(input)
+		if err != nil {
+			return chat, errors.New(fmt.Sprintf("Unable to access file: %s", input))
+		}
+
+		err = parser.Parse(file, number, warn)
+		file.Close()
+
+		if err != nil {
+			return chat, errors.Wrap(err, fmt.Sprintf("failed to read %s as %s", input, f.Name))
+		}
+	}
+
+	if namer, ok := parser.(Namer); ok {
+		chat.Name = namer.ChatName()
+	}
+
+	return chat, nil
+}
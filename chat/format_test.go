@@ -0,0 +1,85 @@
+package chat
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	cases := []struct {
+		name     string
+		contents string
+		format   string
+	}{
+		{
+			name:     "telegram text with html in a message",
+			contents: "01.02.2018 10:00:00, Anna: how do I start an <html> page?\n01.02.2018 10:01:00, Ben: with a doctype\n",
+			format:   "telegram-text",
+		},
+		{
+			name:     "whatsapp with html in a message",
+			contents: "[01.02.18, 10:00:00] Anna: <!DOCTYPE html> then <html>\n[01.02.18, 10:01:00] Ben: ok\n",
+			format:   "whatsapp",
+		},
+		{
+			name:     "telegram html export",
+			contents: "\xef\xbb\xbf<!DOCTYPE html>\n<html>\n <head>\n  <meta charset=\"utf-8\"/>\n </head>\n <body>\n  <div class=\"page_wrap\">\n",
+			format:   "telegram-html",
+		},
+		{
+			name:     "telegram json export",
+			contents: "{\n \"name\": \"Anna\",\n \"type\": \"personal_chat\",\n \"messages\": []\n}\n",
+			format:   "telegram-json",
+		},
+		{
+			name:     "anything else",
+			contents: "nothing to see here\n",
+			format:   defaultFormat,
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "kissyface")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i, c := range cases {
+		// a name that doesn't look like an html export page, so it's the contents that decide
+		path := filepath.Join(dir, string('a'+rune(i))+".txt")
+		if err := ioutil.WriteFile(path, []byte(c.contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		format, err := Detect(path)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if format.Name != c.format {
+			t.Errorf("%s: detected as %s, expected %s", c.name, format.Name, c.format)
+		}
+	}
+}
+
+func TestDetectTelegramHTML(t *testing.T) {
+	cases := []struct {
+		head     string
+		expected bool
+	}{
+		{"<!DOCTYPE html>\n<html>\n<body>\n<div class=\"page_wrap\">", true},
+		{"  \r\n<html><body><div class=\"history\">", true},
+		{"\xef\xbb\xbf<!doctype html><html><div class=\"page_wrap\">", true},
+		{"<!DOCTYPE html>\n<html>\n<body>\n<p>somebody else's page</p>", false},
+		{"how do I start an <html> page? <div class=\"history\">", false},
+		{"", false},
+	}
+
+	for _, c := range cases {
+		if actual := detectTelegramHTML([]byte(c.head)); actual != c.expected {
+			t.Errorf("detectTelegramHTML(%q) = %t, expected %t", c.head, actual, c.expected)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package chat
+
+import (
+	"fmt"
+	"time"
+)
+
+// Message is a single message from a chat log
+type Message struct {
+	// Index is the position of the message in the chat log, counting from 0
+	Index int
+	// Line is the line of the input the message starts on, or 0 for formats which aren't line based
+	Line int
+	Time time.Time
+	User string
+	Body string
+
+	// the remaining fields are only filled in by formats which carry them (eg. Telegram's result.json)
+	ID        int
+	ReplyTo   int
+	MediaType string
+	Entities  []Entity
+}
+
+// Entity is a span of formatted text within a message body (a link, a mention, some bold text etc.)
+type Entity struct {
+	Type string
+	Text string
+}
+
+func (m Message) String() string {
+	return fmt.Sprintf("%d %s %s: %s", m.Index, m.Time, m.User, m.Body)
+}
@@ -0,0 +1,323 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the layout of the tooltip on every message's timestamp, newer exports append the UTC offset of the exporting machine
+const telegramHTMLDate = "02.01.2006 15:04:05"
+const telegramHTMLZonedDate = "02.01.2006 15:04:05 UTC-07:00"
+
+// Telegram Desktop splits long histories across messages.html, messages2.html, messages3.html ...
+var telegramHTMLPage = regexp.MustCompile(`^messages(\d*)\.html$`)
+
+// the css classes Telegram uses to mark up attachments, mapped to the media_type names used in result.json
+var telegramHTMLMedia = map[string]string{
+	"photo_wrap":          "photo",
+	"media_photo":         "photo",
+	"video_file_wrap":     "video_file",
+	"media_video":         "video_file",
+	"animated_wrap":       "animation",
+	"media_voice_message": "voice_message",
+	"media_audio_file":    "audio_file",
+	"media_file":          "file",
+	"sticker":             "sticker",
+	"media_contact":       "contact",
+	"media_location":      "location",
+	"media_live_location": "location",
+	"media_video_message": "video_message",
+}
+
+func init() {
+	Register(Format{
+		Name:     "telegram-html",
+		Priority: 20,
+		Detect:   detectTelegramHTML,
+		Inputs:   telegramHTMLPages,
+		New:      func(location *time.Location) Parser { return &telegramHTMLParser{location: location} },
+	})
+}
+
+// an HTML export is a web page which wraps its messages in Telegram's own page_wrap and history divs. Other
+// formats can have HTML in them too, somebody only has to paste some into a chat.
+func detectTelegramHTML(head []byte) bool {
+	lower := bytes.ToLower(bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")), " \t\r\n"))
+	if !bytes.HasPrefix(lower, []byte("<!doctype html")) && !bytes.HasPrefix(lower, []byte("<html")) {
+		return false
+	}
+	return bytes.Contains(lower, []byte(`class="page_wrap"`)) || bytes.Contains(lower, []byte(`class="history"`))
+}
+
+// telegramHTMLPages returns every page of an HTML export in order, given either the export directory or any one of its pages
+func telegramHTMLPages(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := path
+	if !info.IsDir() {
+		dir = filepath.Dir(path)
+		// a lone html file which doesn't follow the export naming is treated as a single page
+		if !telegramHTMLPage.MatchString(filepath.Base(path)) {
+			return []string{path}, nil
+		}
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "messages*.html"))
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]string, 0, len(matches))
+	numbers := make(map[string]int, len(matches))
+	for _, match := range matches {
+		found := telegramHTMLPage.FindStringSubmatch(filepath.Base(match))
+		if found == nil {
+			continue
+		}
+		// the first page has no number
+		numbers[match] = 1
+		if found[1] != "" {
+			numbers[match], _ = strconv.Atoi(found[1])
+		}
+		pages = append(pages, match)
+	}
+
+	if len(pages) == 0 {
+		return nil, errors.New(fmt.Sprintf("No messages.html pages found in %s", dir))
+	}
+
+	sort.Slice(pages, func(i, j int) bool { return numbers[pages[i]] < numbers[pages[j]] })
+	return pages, nil
+}
+
+func htmlClasses(start xml.StartElement) []string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "class" {
+			return strings.Fields(attr.Value)
+		}
+	}
+	return nil
+}
+
+func htmlAttr(start xml.StartElement, name string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func hasClass(classes []string, want string) bool {
+	for _, class := range classes {
+		if class == want {
+			return true
+		}
+	}
+	return false
+}
+
+// what an open element means to the parser, so we know what to do when it closes
+type htmlRole int
+
+const (
+	htmlOther htmlRole = iota
+	htmlMessage
+	htmlForwarded
+	htmlReply
+	htmlFromName
+	htmlText
+	htmlHeader
+	htmlTitle
+)
+
+// telegramHTMLParser walks the pages of an export, it holds on to the last sender so "joined" messages can be attributed
+type telegramHTMLParser struct {
+	emit     func(Message)
+	warn     func(Diagnostic)
+	location *time.Location
+
+	sender string
+	// the name of the chat, from the header at the top of the first page
+	title  []string
+	header int
+
+	// the message currently being assembled, and the state of the elements we're nested in
+	message   *Message
+	date      string
+	stack     []htmlRole
+	forwarded int
+	reply     int
+	from_name []string
+	text      []string
+	capture   *[]string
+}
+
+func (t *telegramHTMLParser) open(classes []string, start xml.StartElement) htmlRole {
+	if hasClass(classes, "message") && hasClass(classes, "default") {
+		t.message = new(Message)
+		t.date = ""
+		t.from_name = nil
+		t.text = nil
+		t.message.ID, _ = strconv.Atoi(strings.TrimPrefix(htmlAttr(start, "id"), "message"))
+		// joined messages are consecutive messages from the same sender, Telegram doesn't repeat the name
+		if hasClass(classes, "joined") {
+			t.message.User = t.sender
+		}
+		return htmlMessage
+	}
+
+	if t.message == nil {
+		switch {
+		case hasClass(classes, "page_header"):
+			t.header++
+			return htmlHeader
+		case t.header > 0 && hasClass(classes, "bold") && len(t.title) == 0:
+			t.capture = &t.title
+			return htmlTitle
+		}
+		return htmlOther
+	}
+
+	// anything inside a forwarded message or a reply preview describes some other message
+	nested := t.forwarded > 0 || t.reply > 0
+
+	switch {
+	case hasClass(classes, "forwarded"):
+		t.forwarded++
+		return htmlForwarded
+	case hasClass(classes, "reply_to"):
+		t.reply++
+		return htmlReply
+	case t.reply > 0 && start.Name.Local == "a":
+		href := htmlAttr(start, "href")
+		if i := strings.Index(href, "go_to_message"); i >= 0 {
+			t.message.ReplyTo, _ = strconv.Atoi(href[i+len("go_to_message"):])
+		}
+	case !nested && hasClass(classes, "date") && hasClass(classes, "details"):
+		t.date = htmlAttr(start, "title")
+	case !nested && hasClass(classes, "from_name"):
+		t.capture = &t.from_name
+		return htmlFromName
+	case hasClass(classes, "text"):
+		t.capture = &t.text
+		return htmlText
+	case start.Name.Local == "br" && t.capture != nil:
+		*t.capture = append(*t.capture, "\n")
+	}
+
+	for _, class := range classes {
+		if media, ok := telegramHTMLMedia[class]; ok && t.message.MediaType == "" {
+			t.message.MediaType = media
+		}
+	}
+
+	return htmlOther
+}
+
+func (t *telegramHTMLParser) close(role htmlRole) {
+	switch role {
+	case htmlForwarded:
+		t.forwarded--
+	case htmlReply:
+		t.reply--
+	case htmlHeader:
+		t.header--
+	case htmlFromName, htmlText, htmlTitle:
+		t.capture = nil
+	case htmlMessage:
+		t.finish()
+	}
+}
+
+func (t *telegramHTMLParser) finish() {
+	message := t.message
+	t.message = nil
+
+	if name := strings.TrimSpace(strings.Join(t.from_name, "")); name != "" {
+		message.User = name
+	}
+	message.Body = strings.TrimSpace(strings.Join(t.text, ""))
+
+	if len(t.date) < len(telegramHTMLDate) {
+		t.warn(Diagnostic{Text: fmt.Sprintf("message %d", message.ID), Reason: "no date"})
+		return
+	}
+	// when the offset is there it's the last word on when the message was sent
+	date, err := time.Parse(telegramHTMLZonedDate, t.date)
+	if err != nil {
+		date, err = time.ParseInLocation(telegramHTMLDate, t.date[:len(telegramHTMLDate)], t.location)
+	}
+	if err != nil {
+		t.warn(Diagnostic{Text: fmt.Sprintf("message %d", message.ID), Reason: fmt.Sprintf("unreadable date %q", t.date)})
+		return
+	}
+
+	if message.User == "" {
+		t.warn(Diagnostic{Text: fmt.Sprintf("message %d", message.ID), Reason: "no sender"})
+		return
+	}
+	t.sender = message.User
+
+	message.Time = date
+
+	t.emit(*message)
+}
+
+func (t *telegramHTMLParser) Parse(r io.Reader, emit func(Message), warn func(Diagnostic)) error {
+	// the export isn't well formed XML, but the decoder copes fine with HTML when it's told to be lenient
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	t.emit = emit
+	t.warn = warn
+	t.stack = t.stack[:0]
+	t.message = nil
+	t.capture = nil
+	t.header = 0
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			t.stack = append(t.stack, t.open(htmlClasses(token), token))
+		case xml.EndElement:
+			if len(t.stack) == 0 {
+				continue
+			}
+			role := t.stack[len(t.stack)-1]
+			t.stack = t.stack[:len(t.stack)-1]
+			t.close(role)
+		case xml.CharData:
+			if t.capture != nil {
+				*t.capture = append(*t.capture, string(token))
+			}
+		}
+	}
+}
+
+func (t *telegramHTMLParser) ChatName() string {
+	return strings.TrimSpace(strings.Join(t.title, ""))
+}
@@ -0,0 +1,46 @@
+package chat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTelegramHTMLParse(t *testing.T) {
+	// the export was made somewhere five hours behind UTC, the dates with an offset say otherwise
+	location := time.FixedZone("UTC-5", -5*60*60)
+	zone := time.FixedZone("UTC+2", 2*60*60)
+
+	expected := []Message{
+		{Index: 0, ID: 1, Time: time.Date(2026, 10, 18, 21, 4, 11, 0, location), User: "Anna", Body: "hi\nthere"},
+		{Index: 1, ID: 2, Time: time.Date(2026, 10, 18, 21, 5, 0, 0, zone), User: "Ben", Body: "hello", ReplyTo: 1},
+		{Index: 2, ID: 3, Time: time.Date(2026, 10, 18, 21, 6, 0, 0, zone), User: "Ben", Body: "old news"},
+		{Index: 3, ID: 4, Time: time.Date(2026, 10, 18, 21, 7, 30, 0, location), User: "Ben", MediaType: "photo"},
+		{Index: 4, ID: 5, Time: time.Date(2026, 10, 18, 21, 8, 0, 0, location), User: "Anna", MediaType: "voice_message"},
+	}
+
+	messages := make([]Message, 0)
+	chat, err := formats["telegram-html"].ParseFile("testdata/telegram-html", location, func(m Message) { messages = append(messages, m) }, func(d Diagnostic) {
+		t.Errorf("unexpected diagnostic %s", d)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(chat.Inputs) != 2 {
+		t.Errorf("read %d pages, expected 2", len(chat.Inputs))
+	}
+	if chat.Name != "Anna & Ben" {
+		t.Errorf("chat is called %q, expected %q", chat.Name, "Anna & Ben")
+	}
+
+	if len(messages) != len(expected) {
+		t.Fatalf("got %d messages, expected %d", len(messages), len(expected))
+	}
+	for i, m := range messages {
+		e := expected[i]
+		if m.Index != e.Index || m.ID != e.ID || !m.Time.Equal(e.Time) || m.User != e.User || m.Body != e.Body || m.ReplyTo != e.ReplyTo || m.MediaType != e.MediaType {
+			t.Errorf("message %d is #%d %d from %s at %s saying %q in reply to %d with media %q, expected #%d %d from %s at %s saying %q in reply to %d with media %q",
+				i, m.Index, m.ID, m.User, m.Time, m.Body, m.ReplyTo, m.MediaType, e.Index, e.ID, e.User, e.Time, e.Body, e.ReplyTo, e.MediaType)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// the layout of the "date" field in Telegram Desktop's result.json, this is the local time of the exporting machine.
+// Newer exports also have a "date_unixtime" which doesn't depend on knowing where that was.
+const telegramJSONDate = "2006-01-02T15:04:05"
+
+func init() {
+	Register(Format{
+		Name:     "telegram-json",
+		Priority: 10,
+		Detect:   detectTelegramJSON,
+		Inputs:   telegramJSONInputs,
+		New:      func(location *time.Location) Parser { return &telegramJSONParser{location: location} },
+	})
+}
+
+// a result.json export is an object which gets to its list of messages within the first few lines
+func detectTelegramJSON(head []byte) bool {
+	for _, r := range string(head) {
+		if r == '\uFEFF' || unicode.IsSpace(r) {
+			continue
+		}
+		return r == '{' && strings.Contains(string(head), `"messages"`)
+	}
+	return false
+}
+
+// the export directory holds result.json alongside the photos and files folders
+func telegramJSONInputs(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	path = filepath.Join(path, "result.json")
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	return []string{path}, nil
+}
+
+// the subset of Telegram Desktop's result.json that we care about
+type telegramExport struct {
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	Messages []telegramMessage `json:"messages"`
+}
+
+type telegramMessage struct {
+	ID           int              `json:"id"`
+	Type         string           `json:"type"`
+	Date         string           `json:"date"`
+	DateUnixtime string           `json:"date_unixtime"`
+	From         string           `json:"from"`
+	ReplyTo      int              `json:"reply_to_message_id"`
+	MediaType    string           `json:"media_type"`
+	Photo        string           `json:"photo"`
+	Text         json.RawMessage  `json:"text"`
+	TextEntities []telegramEntity `json:"text_entities"`
+}
+
+type telegramEntity struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// the "text" field is either a plain string or a list mixing plain strings and entity objects
+func (t telegramMessage) entities() ([]telegramEntity, error) {
+	// newer exports carry the same thing in a consistent shape, prefer it when it's there
+	if t.TextEntities != nil {
+		return t.TextEntities, nil
+	}
+
+	if len(t.Text) == 0 {
+		return nil, nil
+	}
+
+	var plain string
+	if err := json.Unmarshal(t.Text, &plain); err == nil {
+		return []telegramEntity{{Type: "plain", Text: plain}}, nil
+	}
+
+	var parts []json.RawMessage
+	if err := json.Unmarshal(t.Text, &parts); err != nil {
+		return nil, err
+	}
+
+	entities := make([]telegramEntity, 0, len(parts))
+	for _, part := range parts {
+		var entity telegramEntity
+		if err := json.Unmarshal(part, &plain); err == nil {
+			entity = telegramEntity{Type: "plain", Text: plain}
+		} else if err := json.Unmarshal(part, &entity); err != nil {
+			return nil, err
+		}
+		entities = append(entities, entity)
+	}
+
+	return entities, nil
+}
+
+// timestamp reads whichever of the two date fields is there, the error names the one it couldn't read
+func (t telegramMessage) timestamp(location *time.Location) (time.Time, error) {
+	if t.DateUnixtime != "" {
+		seconds, err := strconv.ParseInt(t.DateUnixtime, 10, 64)
+		if err != nil {
+			return time.Time{}, errors.New(fmt.Sprintf("unreadable date_unixtime %q", t.DateUnixtime))
+		}
+		return time.Unix(seconds, 0).In(location), nil
+	}
+
+	date, err := time.ParseInLocation(telegramJSONDate, t.Date, location)
+	if err != nil {
+		return time.Time{}, errors.New(fmt.Sprintf("unreadable date %q", t.Date))
+	}
+	return date, nil
+}
+
+// telegramJSONParser reads the result.json produced by Telegram Desktop's "Export chat history"
+type telegramJSONParser struct {
+	location *time.Location
+	name     string
+}
+
+func (t *telegramJSONParser) ChatName() string {
+	return t.name
+}
+
+func (t *telegramJSONParser) Parse(r io.Reader, emit func(Message), warn func(Diagnostic)) error {
+	var export telegramExport
+
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return errors.Wrap(err, "failed to decode Telegram JSON export")
+	}
+	t.name = export.Name
+
+	for _, record := range export.Messages {
+		// service messages are things like "X joined the group" or "X pinned a message", nobody said them
+		if record.Type != "message" {
+			continue
+		}
+
+		date, err := record.timestamp(t.location)
+		if err != nil {
+			warn(Diagnostic{Text: fmt.Sprintf("message %d", record.ID), Reason: err.Error()})
+			continue
+		}
+
+		entities, err := record.entities()
+		if err != nil {
+			warn(Diagnostic{Text: fmt.Sprintf("message %d", record.ID), Reason: "unreadable text"})
+			continue
+		}
+
+		message := new(Message)
+		message.Time = date
+		message.ID = record.ID
+		message.ReplyTo = record.ReplyTo
+		message.MediaType = record.MediaType
+		if message.MediaType == "" && record.Photo != "" {
+			message.MediaType = "photo"
+		}
+
+		// deleted accounts come through with a null sender
+		message.User = record.From
+		if message.User == "" {
+			message.User = "Deleted Account"
+		}
+
+		for _, entity := range entities {
+			message.Body += entity.Text
+			message.Entities = append(message.Entities, Entity{Type: entity.Type, Text: entity.Text})
+		}
+
+		emit(*message)
+	}
+
+	return nil
+}
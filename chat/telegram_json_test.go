@@ -0,0 +1,186 @@
+package chat
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTelegramJSONEntities(t *testing.T) {
+	cases := []struct {
+		name     string
+		record   string
+		entities []telegramEntity
+	}{
+		{
+			name:     "plain string",
+			record:   `{"text": "hi there"}`,
+			entities: []telegramEntity{{Type: "plain", Text: "hi there"}},
+		},
+		{
+			name:   "strings mixed with entities",
+			record: `{"text": ["see ", {"type": "link", "text": "https://example.com"}, " and ", {"type": "bold", "text": "hurry"}]}`,
+			entities: []telegramEntity{
+				{Type: "plain", Text: "see "},
+				{Type: "link", Text: "https://example.com"},
+				{Type: "plain", Text: " and "},
+				{Type: "bold", Text: "hurry"},
+			},
+		},
+		{
+			name:     "text_entities over text",
+			record:   `{"text": ["old ", {"type": "bold", "text": "shape"}], "text_entities": [{"type": "plain", "text": "new "}, {"type": "bold", "text": "shape"}]}`,
+			entities: []telegramEntity{{Type: "plain", Text: "new "}, {Type: "bold", Text: "shape"}},
+		},
+		{
+			name:     "empty text_entities over text",
+			record:   `{"text": "", "text_entities": []}`,
+			entities: []telegramEntity{},
+		},
+		{
+			name:     "no text at all",
+			record:   `{"photo": "photos/photo_1.jpg"}`,
+			entities: nil,
+		},
+	}
+
+	for _, c := range cases {
+		var record telegramMessage
+		if err := json.Unmarshal([]byte(c.record), &record); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		entities, err := record.entities()
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(entities, c.entities) {
+			t.Errorf("%s: got %q, expected %q", c.name, entities, c.entities)
+		}
+	}
+
+	var record telegramMessage
+	if err := json.Unmarshal([]byte(`{"text": [42]}`), &record); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := record.entities(); err == nil {
+		t.Errorf("a number in the text array should be unreadable")
+	}
+}
+
+func TestTelegramJSONTimestamp(t *testing.T) {
+	location := time.FixedZone("UTC-5", -5*60*60)
+
+	cases := []struct {
+		name   string
+		record string
+		date   time.Time
+	}{
+		{
+			name:   "date",
+			record: `{"date": "2026-10-18T21:04:11"}`,
+			date:   time.Date(2026, 10, 18, 21, 4, 11, 0, location),
+		},
+		{
+			name:   "date_unixtime",
+			record: `{"date_unixtime": "1792357451"}`,
+			date:   time.Date(2026, 10, 18, 21, 4, 11, 0, time.UTC),
+		},
+		{
+			// the date is the exporting machine's local time, the unixtime doesn't need to know where that was
+			name:   "date_unixtime over date",
+			record: `{"date": "2026-10-18T23:04:11", "date_unixtime": "1792357451"}`,
+			date:   time.Date(2026, 10, 18, 21, 4, 11, 0, time.UTC),
+		},
+	}
+
+	for _, c := range cases {
+		var record telegramMessage
+		if err := json.Unmarshal([]byte(c.record), &record); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		date, err := record.timestamp(location)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if !date.Equal(c.date) {
+			t.Errorf("%s: got %s, expected %s", c.name, date, c.date)
+		}
+	}
+}
+
+func TestTelegramJSONParse(t *testing.T) {
+	export := `{
+ "name": "Anna",
+ "type": "personal_chat",
+ "id": 1,
+ "messages": [
+  {"id": 1, "type": "service", "date": "2026-10-18T21:00:00", "actor": "Anna", "action": "pin_message", "text": ""},
+  {"id": 2, "type": "message", "date": "2026-10-18T21:04:11", "from": "Anna", "text": "hi"},
+  {"id": 3, "type": "message", "date": "2026-10-18T21:05:00", "from": null, "reply_to_message_id": 2, "text": ["look at ", {"type": "link", "text": "this"}]},
+  {"id": 4, "type": "message", "date": "2026-10-18T21:06:00", "from": "Ben", "photo": "photos/photo_1.jpg", "width": 1280, "height": 960, "text": ""},
+  {"id": 5, "type": "message", "date": "2026-10-18T21:07:00", "from": "Ben", "file": "voice_messages/audio_1.ogg", "media_type": "voice_message", "text": ""}
+ ]
+}`
+
+	type message struct {
+		id        int
+		user      string
+		body      string
+		replyTo   int
+		mediaType string
+	}
+	expected := []message{
+		{2, "Anna", "hi", 0, ""},
+		{3, "Deleted Account", "look at this", 2, ""},
+		{4, "Ben", "", 0, "photo"},
+		{5, "Ben", "", 0, "voice_message"},
+	}
+
+	messages := make([]message, 0)
+	parser := &telegramJSONParser{location: time.UTC}
+	err := parser.Parse(strings.NewReader(export), func(m Message) {
+		messages = append(messages, message{m.ID, m.User, m.Body, m.ReplyTo, m.MediaType})
+	}, func(d Diagnostic) {
+		t.Errorf("unexpected diagnostic %s", d)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("got %+v, expected %+v", messages, expected)
+	}
+	if parser.ChatName() != "Anna" {
+		t.Errorf("chat is called %q, expected %q", parser.ChatName(), "Anna")
+	}
+}
+
+func TestTelegramJSONDiagnostic(t *testing.T) {
+	export := `{
+ "name": "Anna",
+ "messages": [
+  {"id": 1, "type": "message", "date": "2026-10-18 21:04", "from": "Anna", "text": "hi"},
+  {"id": 2, "type": "message", "date": "2026-10-18T21:05:00", "date_unixtime": "soon", "from": "Ben", "text": "hello"}
+ ]
+}`
+
+	reasons := make([]string, 0)
+	parser := &telegramJSONParser{location: time.UTC}
+	err := parser.Parse(strings.NewReader(export), func(m Message) {
+		t.Errorf("unexpected message %s", m)
+	}, func(d Diagnostic) {
+		reasons = append(reasons, d.Reason)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{`unreadable date "2026-10-18 21:04"`, `unreadable date_unixtime "soon"`}
+	if !reflect.DeepEqual(reasons, expected) {
+		t.Errorf("got %q, expected %q", reasons, expected)
+	}
+}
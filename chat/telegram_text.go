@@ -0,0 +1,118 @@
+package chat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// records look like "31.12.2019 23:59:59, User: message body"
+var telegramTextRecord = regexp.MustCompile(`(?m)^\d{1,2}\.\d{1,2}\.\d{4} \d{1,2}:\d{2}:\d{2}, `)
+
+// every record starts with a timestamp, any line which doesn't is the next line of the message before it
+var telegramTextTimestamp = regexp.MustCompile(`^\d{1,2}\.\d{1,2}\.\d{4} \d{1,2}:\d{2}:\d{2}`)
+
+func init() {
+	Register(Format{
+		Name: "telegram-text",
+		// the loosest check, and what anything left over is read as anyway
+		Priority: 40,
+		Detect:   func(head []byte) bool { return telegramTextRecord.Match(head) },
+		New:      func(location *time.Location) Parser { return &telegramTextParser{location: location} },
+	})
+}
+
+// telegramTextParser reads the plain text logs kissyface was originally written for
+type telegramTextParser struct {
+	location *time.Location
+}
+
+func (t *telegramTextParser) Parse(r io.Reader, emit func(Message), warn func(Diagnostic)) error {
+	scanner := bufio.NewScanner(r)
+	// a single long message is a single long line, give the scanner some room
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	// a message isn't finished until we see the start of the next one, it might have more lines to come
+	var pending *Message
+	// whether the last record we saw was thrown away, so its continuation lines go the same way
+	discarded := false
+
+	line_count := 0
+	for scanner.Scan() {
+		line_count++
+		line := scanner.Text()
+		if line_count == 1 {
+			line = strings.TrimPrefix(line, "\uFEFF")
+		}
+
+		if !telegramTextTimestamp.MatchString(line) {
+			switch {
+			case pending != nil:
+				pending.Body += "\n" + line
+			case discarded:
+				warn(Diagnostic{Line: line_count, Text: line, Reason: "continues a discarded record"})
+			default:
+				warn(Diagnostic{Line: line_count, Text: line, Reason: "text before the first message"})
+			}
+			continue
+		}
+
+		if pending != nil {
+			emit(*pending)
+			pending = nil
+		}
+
+		message := new(Message)
+		message.Line = line_count
+
+		// parse out the date and time, and make sure it's one that actually exists
+		var day, month, year, hour, minute, second int
+		parsed, err := fmt.Sscanf(line, "%d.%d.%d %d:%d:%d", &day, &month, &year, &hour, &minute, &second)
+		if err != nil || parsed != 6 {
+			warn(Diagnostic{Line: line_count, Text: line, Reason: "unreadable timestamp"})
+			discarded = true
+			continue
+		}
+		if err := checkTimestamp(year, month, day, hour, minute, second); err != nil {
+			warn(Diagnostic{Line: line_count, Text: line, Reason: err.Error()})
+			discarded = true
+			continue
+		}
+		message.Time = time.Date(year, time.Month(month), day, hour, minute, second, 0, t.location)
+
+		separated := strings.SplitN(line, ",", 2)
+		// looks like a safe assumption that correctly formatted records have a comma between the datestamp and the data
+		if len(separated) < 2 {
+			warn(Diagnostic{Line: line_count, Text: line, Reason: "no comma after the date"})
+			discarded = true
+			continue
+		}
+
+		// extract username and message body, again presence of colon seems like a safe assumption
+		userbody := strings.SplitN(separated[1], ":", 2)
+		if len(userbody) < 2 {
+			warn(Diagnostic{Line: line_count, Text: line, Reason: "no colon after the username"})
+			discarded = true
+			continue
+		}
+		message.User = strings.TrimSpace(userbody[0])
+		message.Body = strings.TrimPrefix(userbody[1], " ")
+		if message.User == "" {
+			warn(Diagnostic{Line: line_count, Text: line, Reason: "no username"})
+			discarded = true
+			continue
+		}
+
+		pending = message
+		discarded = false
+	}
+
+	if pending != nil {
+		emit(*pending)
+	}
+
+	return scanner.Err()
+}
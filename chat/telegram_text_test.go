@@ -0,0 +1,59 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTelegramTextParse(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		users  []string
+		bodies []string
+	}{
+		{
+			name:   "plain",
+			input:  "01.02.2018 10:00:00, Anna: first\n01.02.2018 10:01:00, Ben: second\n",
+			users:  []string{"Anna", "Ben"},
+			bodies: []string{"first", "second"},
+		},
+		{
+			name:   "byte order mark",
+			input:  "\xef\xbb\xbf01.02.2018 10:00:00, Anna: first\n01.02.2018 10:01:00, Ben: second\n",
+			users:  []string{"Anna", "Ben"},
+			bodies: []string{"first", "second"},
+		},
+		{
+			name:   "continuation lines",
+			input:  "01.02.2018 10:00:00, Anna: first\nand more\n01.02.2018 10:01:00, Ben: second\n",
+			users:  []string{"Anna", "Ben"},
+			bodies: []string{"first\nand more", "second"},
+		},
+	}
+
+	for _, c := range cases {
+		messages := make([]Message, 0)
+		parser := &telegramTextParser{location: time.UTC}
+		err := parser.Parse(strings.NewReader(c.input), func(m Message) { messages = append(messages, m) }, func(d Diagnostic) {
+			t.Errorf("%s: unexpected diagnostic %s", c.name, d)
+		})
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if len(messages) != len(c.users) {
+			t.Errorf("%s: got %d messages, expected %d", c.name, len(messages), len(c.users))
+			continue
+		}
+		for i, m := range messages {
+			if m.User != c.users[i] || m.Body != c.bodies[i] {
+				t.Errorf("%s: message %d is %q from %q, expected %q from %q", c.name, i, m.Body, m.User, c.bodies[i], c.users[i])
+			}
+		}
+		if sent := time.Date(2018, 2, 1, 10, 0, 0, 0, time.UTC); !messages[0].Time.Equal(sent) {
+			t.Errorf("%s: first message sent at %s, expected %s", c.name, messages[0].Time, sent)
+		}
+	}
+}
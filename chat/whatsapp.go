@@ -0,0 +1,226 @@
+package chat
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WhatsApp's "Export chat" writes the date in the phone's locale, so we have to cope with all of
+//
+//	[18/10/2026, 21:04:11] Name: text       (iOS)
+//	18/10/26, 9:04 PM - Name: text          (Android)
+//	18.10.26, 21:04 - Name: text            (German Android)
+//	[2026-10-18, 9:04:11 p. m.] Name: text  (and so on)
+var whatsappRecord = regexp.MustCompile(`^\[?(\d{1,4})[./-](\d{1,2})[./-](\d{1,4}),? (\d{1,2})[:.](\d{2})(?:[:.](\d{2}))? ?(?:([AaPp])\.? ?[Mm]\.?)?(?:\] | - )(.*)$`)
+
+// the same thing, but for finding a record anywhere in the first few KB of a file
+var whatsappDetect = regexp.MustCompile(`(?m)^\x{200E}?\[?\d{1,4}[./-]\d{1,2}[./-]\d{1,4},? \d{1,2}[:.]\d{2}(?:[:.]\d{2})? ?(?:[AaPp]\.? ?[Mm]\.?)?(?:\] | - )`)
+
+// lines WhatsApp writes itself which look like a message but which nobody sent
+var whatsappSystem = []string{
+	"Messages and calls are end-to-end encrypted",
+	"Messages to this chat and calls are now secured with end-to-end encryption",
+	"Messages to this group are now secured with end-to-end encryption",
+	"Your security code with",
+	"created group",
+	"changed the subject",
+	"changed this group's icon",
+	"changed the group description",
+	"joined using this group's invite link",
+	"changed their phone number",
+	"turned on disappearing messages",
+	"turned off disappearing messages",
+	"Missed voice call",
+	"Missed video call",
+}
+
+// the placeholders left behind when an export is made without media, mapped to the media_type names used in result.json
+var whatsappMedia = map[string]string{
+	"<Media omitted>":      "media",
+	"image omitted":        "photo",
+	"video omitted":        "video_file",
+	"audio omitted":        "voice_message",
+	"sticker omitted":      "sticker",
+	"GIF omitted":          "animation",
+	"document omitted":     "file",
+	"Contact card omitted": "contact",
+}
+
+func init() {
+	Register(Format{
+		Name:     "whatsapp",
+		Priority: 30,
+		Detect:   func(head []byte) bool { return whatsappDetect.Match(head) },
+		New:      func(location *time.Location) Parser { return &whatsappParser{location: location} },
+	})
+}
+
+// a record as it appears in the file, before we know which order the date is written in
+type whatsappLine struct {
+	line int
+	// text is the line just as it was in the file, for diagnostics
+	text   string
+	date   [3]int
+	width  [3]int
+	hour   int
+	minute int
+	second int
+	ampm   string
+	rest   string
+	body   []string
+}
+
+// whatsappParser reads the text file produced by WhatsApp's "Export chat"
+type whatsappParser struct {
+	location *time.Location
+}
+
+func (w *whatsappParser) Parse(r io.Reader, emit func(Message), warn func(Diagnostic)) error {
+	records := make([]*whatsappLine, 0)
+
+	scanner := bufio.NewScanner(r)
+	// a single long message is a single long line, give the scanner some room
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line_count := 0
+	for scanner.Scan() {
+		line_count++
+		line := scanner.Text()
+		if line_count == 1 {
+			line = strings.TrimPrefix(line, "\uFEFF")
+		}
+
+		// iOS sprinkles left-to-right marks around, and newer versions put a narrow space before AM/PM
+		header := strings.Replace(strings.TrimPrefix(line, "\u200E"), "\u202F", " ", -1)
+		header = strings.Replace(header, "\u00A0", " ", -1)
+
+		found := whatsappRecord.FindStringSubmatch(header)
+		if found == nil {
+			// anything which doesn't start with a date is the next line of a multi-line message
+			if len(records) == 0 {
+				warn(Diagnostic{Line: line_count, Text: line, Reason: "text before the first message"})
+				continue
+			}
+			previous := records[len(records)-1]
+			previous.body = append(previous.body, line)
+			continue
+		}
+
+		record := &whatsappLine{line: line_count, text: line, ampm: strings.ToLower(found[7]), rest: found[8]}
+		for i := 0; i < 3; i++ {
+			record.date[i], _ = strconv.Atoi(found[i+1])
+			record.width[i] = len(found[i+1])
+		}
+		record.hour, _ = strconv.Atoi(found[4])
+		record.minute, _ = strconv.Atoi(found[5])
+		record.second, _ = strconv.Atoi(found[6])
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	day, month, year := whatsappDateOrder(records)
+
+	for _, record := range records {
+		// names never contain ": ", and most system messages don't either. The ones that do (eg. a new group
+		// subject) give themselves away before the colon.
+		userbody := strings.SplitN(record.rest, ": ", 2)
+		if len(userbody) < 2 || whatsappIsSystem(userbody[0]) {
+			continue
+		}
+
+		body := strings.Join(append([]string{userbody[1]}, record.body...), "\n")
+		// iOS writes system messages as if the chat itself sent them, with a mark in front of the body
+		if strings.HasPrefix(body, "\u200E") {
+			body = strings.TrimPrefix(body, "\u200E")
+			if whatsappIsSystem(body) {
+				continue
+			}
+		}
+
+		hour := record.hour
+		switch {
+		case record.ampm == "a" && hour == 12:
+			hour = 0
+		case record.ampm == "p" && hour < 12:
+			hour += 12
+		}
+
+		y := record.date[year]
+		// two digit years are always this century, WhatsApp hasn't been around for long enough for anything else
+		if record.width[year] <= 2 {
+			y += 2000
+		}
+
+		if err := checkTimestamp(y, record.date[month], record.date[day], hour, record.minute, record.second); err != nil {
+			warn(Diagnostic{Line: record.line, Text: record.text, Reason: err.Error()})
+			continue
+		}
+
+		message := new(Message)
+		message.Line = record.line
+		message.Time = time.Date(y, time.Month(record.date[month]), record.date[day], hour, record.minute, record.second, 0, w.location)
+		message.User = userbody[0]
+		message.Body = body
+
+		if media, ok := whatsappMedia[strings.TrimSpace(body)]; ok {
+			message.MediaType = media
+			message.Body = ""
+		} else if strings.HasPrefix(body, "<attached: ") {
+			message.MediaType = "file"
+			message.Body = ""
+		}
+
+		emit(*message)
+	}
+
+	return nil
+}
+
+func whatsappIsSystem(text string) bool {
+	for _, phrase := range whatsappSystem {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// whatsappDateOrder works out which of the three numbers in every date are the day, month and year. The locale
+// isn't written down anywhere, but across a whole chat the dates almost always give it away.
+func whatsappDateOrder(records []*whatsappLine) (day int, month int, year int) {
+	first_over_12, second_over_12, twelve_hour := false, false, false
+
+	for _, record := range records {
+		// a four digit number up front is an ISO style year-month-day
+		if record.width[0] == 4 {
+			return 2, 1, 0
+		}
+		if record.date[0] > 12 {
+			first_over_12 = true
+		}
+		if record.date[1] > 12 {
+			second_over_12 = true
+		}
+		if record.ampm != "" {
+			twelve_hour = true
+		}
+	}
+
+	switch {
+	case first_over_12:
+		return 0, 1, 2
+	case second_over_12:
+		return 1, 0, 2
+	case twelve_hour:
+		// nothing to go on, but a 12 hour clock is a good hint we're in the US
+		return 1, 0, 2
+	default:
+		return 0, 1, 2
+	}
+}
@@ -0,0 +1,162 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWhatsappParse(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		times  []time.Time
+		users  []string
+		bodies []string
+	}{
+		{
+			name:  "iOS",
+			input: "[18/10/2026, 21:04:11] Anna: hi\n\u200E[18/10/2026, 21:05:00] Ben: hello\n",
+			times: []time.Time{
+				time.Date(2026, 10, 18, 21, 4, 11, 0, time.UTC),
+				time.Date(2026, 10, 18, 21, 5, 0, 0, time.UTC),
+			},
+			users:  []string{"Anna", "Ben"},
+			bodies: []string{"hi", "hello"},
+		},
+		{
+			name:  "Android, 12 hour clock and US dates",
+			input: "10/3/26, 9:04 PM - Anna: hi\n10/18/26, 12:15 AM - Ben: hello\n",
+			times: []time.Time{
+				time.Date(2026, 10, 3, 21, 4, 0, 0, time.UTC),
+				time.Date(2026, 10, 18, 0, 15, 0, 0, time.UTC),
+			},
+			users:  []string{"Anna", "Ben"},
+			bodies: []string{"hi", "hello"},
+		},
+		{
+			name:  "Android, day first",
+			input: "3/10/26, 21:04 - Anna: hi\n18/10/26, 09:15 - Ben: hello\n",
+			times: []time.Time{
+				time.Date(2026, 10, 3, 21, 4, 0, 0, time.UTC),
+				time.Date(2026, 10, 18, 9, 15, 0, 0, time.UTC),
+			},
+			users:  []string{"Anna", "Ben"},
+			bodies: []string{"hi", "hello"},
+		},
+		{
+			name:  "German Android",
+			input: "18.10.26, 21:04 - Anna: hallo\n19.10.26, 07:30 - Ben: moin\n",
+			times: []time.Time{
+				time.Date(2026, 10, 18, 21, 4, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 7, 30, 0, 0, time.UTC),
+			},
+			users:  []string{"Anna", "Ben"},
+			bodies: []string{"hallo", "moin"},
+		},
+		{
+			name:   "ISO dates, Spanish AM/PM and a narrow space",
+			input:  "[2026-10-18, 9:04:11\u202Fp. m.] Anna: hola\n",
+			times:  []time.Time{time.Date(2026, 10, 18, 21, 4, 11, 0, time.UTC)},
+			users:  []string{"Anna"},
+			bodies: []string{"hola"},
+		},
+		{
+			name:   "system messages and continuation lines",
+			input:  "[18/10/2026, 21:04:11] Anna: \u200EMessages and calls are end-to-end encrypted.\n[18/10/2026, 21:05:00] Ben: one\ntwo\n",
+			times:  []time.Time{time.Date(2026, 10, 18, 21, 5, 0, 0, time.UTC)},
+			users:  []string{"Ben"},
+			bodies: []string{"one\ntwo"},
+		},
+	}
+
+	for _, c := range cases {
+		messages := make([]Message, 0)
+		parser := &whatsappParser{location: time.UTC}
+		err := parser.Parse(strings.NewReader(c.input), func(m Message) { messages = append(messages, m) }, func(d Diagnostic) {
+			t.Errorf("%s: unexpected diagnostic %s", c.name, d)
+		})
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if len(messages) != len(c.times) {
+			t.Errorf("%s: got %d messages, expected %d", c.name, len(messages), len(c.times))
+			continue
+		}
+		for i, m := range messages {
+			if !m.Time.Equal(c.times[i]) {
+				t.Errorf("%s: message %d sent at %s, expected %s", c.name, i, m.Time, c.times[i])
+			}
+			if m.User != c.users[i] {
+				t.Errorf("%s: message %d sent by %q, expected %q", c.name, i, m.User, c.users[i])
+			}
+			if m.Body != c.bodies[i] {
+				t.Errorf("%s: message %d says %q, expected %q", c.name, i, m.Body, c.bodies[i])
+			}
+		}
+	}
+}
+
+func TestWhatsappDateOrder(t *testing.T) {
+	cases := []struct {
+		name             string
+		records          []*whatsappLine
+		day, month, year int
+	}{
+		{
+			name:    "day over 12 up front",
+			records: []*whatsappLine{{date: [3]int{3, 4, 26}}, {date: [3]int{18, 4, 26}}},
+			day:     0, month: 1, year: 2,
+		},
+		{
+			name:    "day over 12 in the middle",
+			records: []*whatsappLine{{date: [3]int{4, 3, 26}}, {date: [3]int{4, 18, 26}}},
+			day:     1, month: 0, year: 2,
+		},
+		{
+			name:    "ambiguous with a 12 hour clock",
+			records: []*whatsappLine{{date: [3]int{4, 3, 26}, ampm: "p"}},
+			day:     1, month: 0, year: 2,
+		},
+		{
+			name:    "ambiguous with a 24 hour clock",
+			records: []*whatsappLine{{date: [3]int{4, 3, 26}}},
+			day:     0, month: 1, year: 2,
+		},
+		{
+			name:    "four digit year up front",
+			records: []*whatsappLine{{date: [3]int{2026, 4, 3}, width: [3]int{4, 2, 2}}},
+			day:     2, month: 1, year: 0,
+		},
+		{
+			name: "no records at all",
+			day:  0, month: 1, year: 2,
+		},
+	}
+
+	for _, c := range cases {
+		day, month, year := whatsappDateOrder(c.records)
+		if day != c.day || month != c.month || year != c.year {
+			t.Errorf("%s: got day %d, month %d, year %d, expected %d, %d, %d", c.name, day, month, year, c.day, c.month, c.year)
+		}
+	}
+}
+
+func TestWhatsappDiagnostic(t *testing.T) {
+	line := "[31/02/2019, 10:00:00] Anna: the 31st of February"
+	diagnostics := make([]Diagnostic, 0)
+	parser := &whatsappParser{location: time.UTC}
+	err := parser.Parse(strings.NewReader(line+"\n[01/03/2019, 10:00:00] Ben: ok\n"), func(Message) {}, func(d Diagnostic) {
+		diagnostics = append(diagnostics, d)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diagnostics) != 1 {
+		t.Fatalf("got %d diagnostics, expected 1", len(diagnostics))
+	}
+	if diagnostics[0].Text != line || diagnostics[0].Line != 1 {
+		t.Errorf("got line %d %q, expected line 1 %q", diagnostics[0].Line, diagnostics[0].Text, line)
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/rsalmond/kissyface/chat"
+	"github.com/rsalmond/kissyface/stats"
+	"io"
+)
+
+// analysis is everything a chat is fed through on its way in
+type analysis struct {
+	histo    *stats.Histogram
+	replies  *stats.ReplyTimes
+	sessions *stats.Sessions
+	words    *stats.WordCounts
+	emoji    *stats.EmojiCounts
+	lengths  *stats.MessageLengths
+	// top is how many of each user's words and emoji to list
+	top int
+}
+
+// feed hands a single message to every analyzer
+func (a analysis) feed(m chat.Message) {
+	a.histo.Feed(m)
+	a.replies.Feed(m)
+	a.sessions.Feed(m)
+	a.words.Feed(m)
+	a.emoji.Feed(m)
+	a.lengths.Feed(m)
+}
+
+// csvAnalyses are the analyses whose CSV files are only written when --csv asks for them
+var csvAnalyses = []string{"replies", "sessions", "initiative", "words", "emoji", "lengths"}
+
+// csvFile is one of the CSV files the analysis can be written out as
+type csvFile struct {
+	// analysis is what --csv calls the analysis the file belongs to, empty for the files that are always written
+	analysis string
+	name     string
+	write    func(io.Writer, stats.CSVOptions) error
+}
+
+// csvFiles lists every CSV file the analysis can write, with the all time series counted up in each of resolutions
+func (a analysis) csvFiles(resolutions []stats.Resolution) []csvFile {
+	distinctive := func(w io.Writer, opts stats.CSVOptions) error { return a.words.WriteDistinctiveCSV(w, opts, a.top) }
+	monthly_lengths := func(w io.Writer, opts stats.CSVOptions) error {
+		return a.lengths.WriteSeriesCSV(w, opts, stats.PerMonth)
+	}
+
+	files := []csvFile{
+		{"", "weekday.csv", a.histo.WriteWeekdayCSV},
+		{"", "hourly.csv", a.histo.WriteHourlyCSV},
+		{"", "heatmap.csv", a.histo.WriteHeatmapCSV},
+		{"replies", "reply_times.csv", a.replies.WriteDistributionCSV},
+		{"replies", "reply_times_by_hour.csv", a.replies.WriteByHourCSV},
+		{"sessions", "sessions.csv", a.sessions.WriteCSV},
+		{"sessions", "sessions_by_weekday.csv", a.sessions.WriteWeekdayCSV},
+		{"sessions", "sessions_by_hour.csv", a.sessions.WriteHourlyCSV},
+		{"initiative", "initiative_by_weekday.csv", a.sessions.WriteInitiativeWeekdayCSV},
+		{"initiative", "initiative_by_hour.csv", a.sessions.WriteInitiativeHourlyCSV},
+		{"words", "words.csv", a.words.WriteCSV},
+		{"words", "vocabulary.csv", a.words.WriteVocabularyCSV},
+		{"words", "distinctive_words.csv", distinctive},
+		{"emoji", "emoji.csv", a.emoji.WriteCSV},
+		{"emoji", "emoji_by_month.csv", a.emoji.WriteMonthlyCSV},
+		{"lengths", "message_lengths.csv", a.lengths.WriteCSV},
+		{"lengths", "message_length_distribution.csv", a.lengths.WriteDistributionCSV},
+		{"lengths", "message_lengths_by_month.csv", monthly_lengths},
+	}
+
+	for _, r := range []stats.Resolution{stats.PerDay, stats.PerWeek} {
+		r := r
+		write := func(w io.Writer, opts stats.CSVOptions) error { return a.sessions.WriteSeriesCSV(w, opts, r) }
+		files = append(files, csvFile{"sessions", fmt.Sprintf("sessions_per_%s.csv", r), write})
+	}
+
+	for _, r := range resolutions {
+		r := r
+		write := func(w io.Writer, opts stats.CSVOptions) error { return a.histo.WriteSeriesCSV(w, opts, r) }
+		files = append(files, csvFile{"", fmt.Sprintf("all_time_by_%s.csv", r), write})
+	}
+
+	return files
+}
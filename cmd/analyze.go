@@ -2,365 +2,408 @@ package cmd
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"github.com/pkg/errors"
+	"github.com/rsalmond/kissyface/chart"
+	"github.com/rsalmond/kissyface/chat"
+	"github.com/rsalmond/kissyface/stats"
 	"os"
+	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
-func parseArgs() (filename string, err error) {
-	if len(os.Args) != 2 {
-		return "", errors.New(fmt.Sprintf("Usage: %s \"<filename>\"\n", os.Args[0]))
-	} else {
-		return os.Args[1], nil
-	}
+// ErrUsage is returned by Analyze when the command line didn't make sense, the usage has already been printed by then
+var ErrUsage = errors.New("invalid command line")
+
+// options collects everything the user told us on the command line
+type options struct {
+	filename  string
+	format    string
+	rejects   string
+	location  string
+	userZones userZones
+	outDir    string
+	prefix    string
+	noClobber bool
+	weekStart string
+	delimiter string
+	bom       bool
+	json      string
+	html      string
+	svg       bool
+	png       bool
+	chartSize string
+	charts    bool
+	buckets   string
+	analyses  string
+	cutoff    time.Duration
+	gap       time.Duration
+	stopWords string
+	top       int
+	wall      int
 }
 
-type Message struct {
-	Body   string
-	User   string
-	Day    int
-	Month  int
-	Year   int
-	Hour   int
-	Minute int
-	Second int
-}
+// userZones collects the repeatable --user-tz "Name=Zone" flag
+type userZones map[string]*time.Location
 
-type Histogram struct {
-	Hours         map[int]map[string]int
-	Hourly        map[time.Time]map[string]int
-	HourlyOrder   []time.Time
-	Weekdays      map[time.Weekday]map[string]int
-	Users         map[string]int
-	TotalMessages int
+func (u userZones) String() string {
+	zones := make([]string, 0, len(u))
+	for user, location := range u {
+		zones = append(zones, fmt.Sprintf("%s=%s", user, location))
+	}
+	sort.Strings(zones)
+	return strings.Join(zones, " ")
 }
 
-func (h *Histogram) init() {
-	// prepare all the histogram data
-	h.Hours = make(map[int]map[string]int, 24)
-	h.Hourly = make(map[time.Time]map[string]int)
-	h.HourlyOrder = make([]time.Time, 0)
-	h.Weekdays = make(map[time.Weekday]map[string]int, 7)
-	h.Users = make(map[string]int, 2)
-	h.TotalMessages = 0
-}
+func (u userZones) Set(value string) error {
+	// the user goes up to the last =, it's a lot more likely to turn up in a name than in a zone
+	split := strings.LastIndex(value, "=")
+	if split < 1 {
+		return errors.New(fmt.Sprintf("expected Name=Zone, got %q", value))
+	}
 
-func (h *Histogram) count(m *Message) {
-	// count every mesage
-	h.TotalMessages++
-	// sum up total messages by hour of the day they were sent
-	if _, hour_present := h.Hours[m.Hour]; hour_present {
-		if _, user_present := h.Hours[m.Hour][m.User]; user_present {
-			h.Hours[m.Hour][m.User]++
-		} else {
-			h.Hours[m.Hour][m.User] = 1
-		}
-	} else {
-		h.Hours[m.Hour] = make(map[string]int, 2)
-		h.Hours[m.Hour][m.User] = 1
+	location, err := time.LoadLocation(value[split+1:])
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unknown time zone for %s", value[:split]))
 	}
 
-	// and by day of the week
-	date := time.Date(m.Year, time.Month(m.Month), m.Day, m.Hour, 0, 0, 0, time.UTC)
-	if _, date_present := h.Weekdays[date.Weekday()]; date_present {
-		if _, user_present := h.Weekdays[date.Weekday()][m.User]; user_present {
-			h.Weekdays[date.Weekday()][m.User]++
-		} else {
-			h.Weekdays[date.Weekday()][m.User] = 1
-		}
+	u[value[:split]] = location
+	return nil
+}
 
-	} else {
-		h.Weekdays[date.Weekday()] = make(map[string]int, 2)
-		h.Weekdays[date.Weekday()][m.User] = 1
+func parseArgs(args []string) (opts options, err error) {
+	opts.userZones = make(userZones)
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.StringVar(&opts.format, "format", "auto", fmt.Sprintf("input format, one of: auto, %s", strings.Join(chat.Formats(), ", ")))
+	flags.StringVar(&opts.rejects, "rejects", "", "write every malformed record to this file")
+	flags.StringVar(&opts.location, "tz", "Local", "time zone the chat log's timestamps are in, eg. Europe/London")
+	flags.Var(opts.userZones, "user-tz", "time zone a user lives in, as \"Name=Zone\" (may be repeated)")
+	flags.StringVar(&opts.outDir, "out", ".", "directory to write output files to")
+	flags.StringVar(&opts.prefix, "prefix", "", "start output filenames with this (default the chat's name, or the input's filename)")
+	flags.StringVar(&opts.weekStart, "week-start", "Sunday", "day of the week to list weekdays from")
+	flags.StringVar(&opts.delimiter, "delimiter", ",", "field delimiter for CSV files, eg. \";\" for Excel in much of Europe, or \"tab\"")
+	flags.BoolVar(&opts.bom, "bom", false, "start CSV files with a UTF-8 byte order mark so Excel reads names correctly")
+	flags.StringVar(&opts.json, "json", "", "write the whole analysis as JSON to this file")
+	flags.StringVar(&opts.html, "html", "", "write a report with charts, that opens in any web browser, to this file")
+	flags.BoolVar(&opts.svg, "svg", false, "draw the histograms as SVG charts alongside the CSV files")
+	flags.BoolVar(&opts.png, "png", false, "draw the histograms as PNG images alongside the CSV files")
+	flags.StringVar(&opts.chartSize, "chart-size", "800x400", "width and height of SVG and PNG charts in pixels")
+	flags.BoolVar(&opts.charts, "charts", false, "draw bar charts of the histograms in the console report")
+	flags.StringVar(&opts.buckets, "bucket", "hour", "count messages over all time per hour, day, week, month or year (or several, eg. \"week,month\")")
+	flags.StringVar(&opts.analyses, "csv", "", fmt.Sprintf("write the CSV files for these analyses too, any of: %s or all (eg. \"replies,words\")", strings.Join(csvAnalyses, ", ")))
+	flags.DurationVar(&opts.cutoff, "reply-cutoff", 4*time.Hour, "longest gap between messages that still counts as a reply")
+	flags.DurationVar(&opts.gap, "session-gap", 30*time.Minute, "longest silence a conversation can carry on through")
+	flags.StringVar(&opts.stopWords, "stop-words", "en", fmt.Sprintf("stop words to leave out of word counts, any of: %s, none or the name of a file of them, one to a line (eg. \"en,de\")", strings.Join(stats.StopWordLanguages(), ", ")))
+	flags.IntVar(&opts.top, "top", 10, "how many of each user's most used words and emoji to list")
+	flags.IntVar(&opts.wall, "wall-of-text", 500, "how many characters a message has to go over to count as a wall of text")
+	flags.BoolVar(&opts.noClobber, "no-clobber", false, "never overwrite output files, number new ones instead")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] \"<filename>\"\n", args[0])
+		flags.PrintDefaults()
 	}
 
-	// and by messages per hour across all time (broken out by user)
-	if _, date_present := h.Hourly[date]; date_present {
-		if _, user_present := h.Hourly[date][m.User]; user_present {
-			h.Hourly[date][m.User]++
-		} else {
-			h.Hourly[date][m.User] = 1
+	if err := flags.Parse(args[1:]); err != nil {
+		// the flag package has already said what was wrong, and printed the usage
+		if err == flag.ErrHelp {
+			return opts, err
 		}
-	} else {
-		h.Hourly[date] = make(map[string]int, 2)
-		h.Hourly[date][m.User] = 1
+		return opts, ErrUsage
 	}
-	// store these hourly increments in an ordered slice so we can write out the map in order later
-	h.HourlyOrder = append(h.HourlyOrder, date)
-
-	// and by user who sent them
-	if _, present := h.Users[m.User]; present {
-		h.Users[m.User]++
-	} else {
 
-		h.Users[m.User] = 1
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return opts, ErrUsage
 	}
 
-	return
+	opts.filename = flags.Arg(0)
+	return opts, nil
 }
 
-func (h Histogram) write_alltime_csv() (error) {
-	const filename string = "./all_time_by_hour.csv"
-
-	f, err := os.Create(filename)
-	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("failed to write csv file %s", filename))
+// parseWeekday reads the name of a day of the week, or enough of it to tell which one is meant (eg. "mon")
+func parseWeekday(name string) (time.Weekday, error) {
+	if len(name) >= 2 {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.HasPrefix(strings.ToLower(day.String()), strings.ToLower(name)) {
+				return day, nil
+			}
+		}
 	}
+	return time.Sunday, errors.New(fmt.Sprintf("Unknown day of the week: %s", name))
+}
 
-	defer f.Close()
-
-	// this will hold the mapping for each user to the number of messages for the current row of output
-	current_row := make(map[string]int)
-
-	// used to populate the headers and individual data rows
-	usernames := make([]string, 0)
-	var messages []string
-
-	// initialize
-	for user, _ := range h.Users {
-		current_row[user] = 0
-		usernames = append(usernames, user)
+// parseDelimiter reads the CSV delimiter, which has to be a single character that can't be mistaken for part of a field
+func parseDelimiter(delimiter string) (rune, error) {
+	if delimiter == "tab" || delimiter == "\\t" {
+		return '\t', nil
 	}
 
-	// write header
-	f.WriteString(fmt.Sprintf("Hour, %s\n", strings.Join(usernames, ",")))
+	runes := []rune(delimiter)
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\r' || runes[0] == '\n' || runes[0] == utf8.RuneError {
+		return 0, errors.New(fmt.Sprintf("Unusable CSV delimiter: %q", delimiter))
+	}
+	return runes[0], nil
+}
 
-	// get the first and last elements from the ordered slice of all messages for start / end times
-	// probably don't even need this whole slice but whatever
-	start_time := h.HourlyOrder[0]
-	end_time := h.HourlyOrder[len(h.HourlyOrder)-1]
+// parseSize reads a chart size given as WIDTHxHEIGHT, anything much smaller than the default has no room for the data
+func parseSize(size string) (int, int, error) {
+	var width, height int
+	var rest string
+	if n, _ := fmt.Sscanf(size, "%dx%d%s", &width, &height, &rest); n != 2 || width < 300 || height < 200 {
+		return 0, 0, errors.New(fmt.Sprintf("Unusable chart size: %s, it should be at least 300x200", size))
+	}
+	return width, height, nil
+}
 
-	// loop over every hour from the time of the very first message
-	for current_hour := start_time; current_hour.Before(end_time); current_hour = current_hour.Add(time.Hour * 1) {
-		// reset the message slice for each row we write
-		messages = make([]string, 0)
-		// check if we have any messages counted in this hour
-		if _, ok := h.Hourly[current_hour]; ok {
-			// if we do, record the count for that user in the current row
-			for user, messages := range h.Hourly[current_hour] {
-				current_row[user] = messages
+// parseBuckets reads a comma separated list of time series resolutions
+func parseBuckets(buckets string) ([]stats.Resolution, error) {
+	resolutions := make([]stats.Resolution, 0)
+	for _, name := range strings.Split(buckets, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for _, r := range stats.Resolutions() {
+			if r.String() == name {
+				resolutions = append(resolutions, r)
+				found = true
 			}
-
 		}
-		// generate the CSV string values for this row of data (and reset the counts in preparation for the next row)
-		for _, user := range usernames {
-			messages = append(messages, fmt.Sprintf("%d", current_row[user]))
-			current_row[user] = 0
+		if !found {
+			return nil, errors.New(fmt.Sprintf("Unknown bucket: %s, it should be one of hour, day, week, month or year", name))
 		}
-		// write it
-		f.WriteString(fmt.Sprintf("%s, %s\n", current_hour, strings.Join(messages, ",")))
-		// reset
-		messages = nil
 	}
-	return nil
+	return resolutions, nil
 }
 
-func (h Histogram) write_hourly_csv() (error) {
-	const filename string = "./hourly.csv"
-	f, err := os.Create(filename)
-	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("failed to write csv file %s", filename))
+// parseAnalyses reads the comma separated list of analyses to write the CSV files of, "all" asks for every one of them
+func parseAnalyses(names string) (map[string]bool, error) {
+	wanted := make(map[string]bool)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, analysis := range csvAnalyses {
+			if name == analysis || name == "all" {
+				wanted[analysis] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.New(fmt.Sprintf("Unknown --csv analysis: %s, it should be one of %s or all", name, strings.Join(csvAnalyses, ", ")))
+		}
 	}
+	return wanted, nil
+}
 
-	defer f.Close()
-
-	// this will hold the mapping for each user to the number of messages for the current row of output
-	current_row := make(map[string]int)
-
-	// used to populate the headers and individual data rows
-	usernames := make([]string, 0)
-	var messages []string
-
-	// initialize
-	for user, _ := range h.Users {
-		current_row[user] = 0
-		usernames = append(usernames, user)
-	}
+// parseStopWords reads a comma separated list of languages to leave the stop words of out of word counts, each of
+// which can instead be a file of stop words, one to a line
+func parseStopWords(languages string) ([]string, error) {
+	words := make([]string, 0)
+	for _, language := range strings.Split(languages, ",") {
+		language = strings.TrimSpace(language)
+		if language == "" || language == "none" {
+			continue
+		}
 
-	// write header
-	f.WriteString(fmt.Sprintf("Hour, %s\n", strings.Join(usernames, ",")))
+		if list, present := stats.StopWords(language); present {
+			words = append(words, list...)
+			continue
+		}
 
-	for hour, _ := range h.Hours {
-		messages = make([]string, 0)
-		if _, ok := h.Hours[hour]; ok {
-			for user, messages := range h.Hours[hour] {
-				current_row[user] = messages
+		f, err := os.Open(language)
+		if os.IsNotExist(err) {
+			return nil, errors.New(fmt.Sprintf("Unknown stop words: %s, it should be one of %s, none or a file", language, strings.Join(stats.StopWordLanguages(), ", ")))
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to read stop words from %s", language))
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if word := strings.TrimSpace(scanner.Text()); word != "" {
+				words = append(words, word)
 			}
 		}
-		for _, user := range usernames {
-			messages = append(messages, fmt.Sprintf("%d", current_row[user]))
-			current_row[user] = 0
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to read stop words from %s", language))
 		}
-		f.WriteString(fmt.Sprintf("%02d:00, %s\n", hour, strings.Join(messages, ",")))
-		messages = nil
 	}
-
-	return nil
-
+	return words, nil
 }
 
-func (h Histogram) write_weekday_csv() (error) {
-	const filename string = "./weekday.csv"
+// Analyze runs kissyface with the given command line (including the program name, as in os.Args)
+func Analyze(args []string) error {
+	opts, err := parseArgs(args)
 
-	f, err := os.Create(filename)
+	if err == flag.ErrHelp {
+		// asking for the usage isn't a mistake
+		return nil
+	}
 	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("failed to write csv file %s", filename))
+		return err
 	}
 
-	defer f.Close()
-	// this will hold the mapping for each user to the number of messages for the current row of output
-	current_row := make(map[string]int)
+	filename := opts.filename
 
-	// used to populate the headers and individual data rows
-	usernames := make([]string, 0)
-	var messages []string
-
-	// initialize
-	for user, _ := range h.Users {
-		current_row[user] = 0
-		usernames = append(usernames, user)
+	// Make sure the file exists
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return errors.New(fmt.Sprintf("Unable to open file: %s", filename))
 	}
 
-	// write header
-	f.WriteString(fmt.Sprintf("Day, %s\n", strings.Join(usernames, ",")))
-
-	for day, _ := range h.Weekdays {
-		messages = make([]string, 0)
-		if _, ok := h.Weekdays[day]; ok {
-			for user, messages := range h.Weekdays[day] {
-				current_row[user] = messages
-			}
-		}
-		for _, user := range usernames {
-			messages = append(messages, fmt.Sprintf("%d", current_row[user]))
-			current_row[user] = 0
-		}
-		f.WriteString(fmt.Sprintf("%s, %s\n", day.String(), strings.Join(messages, ",")))
-		messages = nil
+	location, err := time.LoadLocation(opts.location)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Unknown time zone: %s", opts.location))
 	}
 
-	return nil
-}
-
-func (h Histogram) get_chattiest_day() (map[string]time.Weekday, map[string]int) {
-
-	var chattiest_day = make(map[string]time.Weekday)
-	var chattiest_day_messages = make(map[string]int)
-
-	for day, users := range h.Weekdays {
-		for user, messages := range users {
-			if messages > chattiest_day_messages[user] {
-				chattiest_day[user] = day
-				chattiest_day_messages[user] = messages
-			}
-		}
+	week_start, err := parseWeekday(opts.weekStart)
+	if err != nil {
+		return err
 	}
 
-	return chattiest_day, chattiest_day_messages
-}
+	delimiter, err := parseDelimiter(opts.delimiter)
+	if err != nil {
+		return err
+	}
 
-func (h Histogram) get_chattiest_hour() (map[string]int, map[string]int) {
-	var chattiest_hour = make(map[string]int)
-	var chattiest_hour_messages = make(map[string]int)
+	chart_width, chart_height, err := parseSize(opts.chartSize)
+	if err != nil {
+		return err
+	}
 
-	for hour, users := range h.Hours {
-		for user, messages := range users {
-			if messages > chattiest_hour_messages[user] {
-				chattiest_hour[user] = hour
-				chattiest_hour_messages[user] = messages
-			}
-		}
+	resolutions, err := parseBuckets(opts.buckets)
+	if err != nil {
+		return err
 	}
 
-	return chattiest_hour, chattiest_hour_messages
-}
+	csv_analyses, err := parseAnalyses(opts.analyses)
+	if err != nil {
+		return err
+	}
 
-func (h Histogram) report() {
-	fmt.Printf("Total message sent: %d\n", h.TotalMessages)
-	for user, messages := range h.Users {
-		fmt.Printf("User %s sent %d messages in total.\n", strings.Trim(user, " "), messages)
+	if opts.cutoff <= 0 {
+		return errors.New(fmt.Sprintf("Unusable reply cutoff: %s", opts.cutoff))
+	}
+	if opts.gap <= 0 {
+		return errors.New(fmt.Sprintf("Unusable session gap: %s", opts.gap))
 	}
 
-	day, daily_messages := h.get_chattiest_day()
-	for user, _ := range day {
-		fmt.Printf("%s sends the most messages on %s, %d all told!\n", user, day[user], daily_messages[user])
+	stop_words, err := parseStopWords(opts.stopWords)
+	if err != nil {
+		return err
+	}
+	if opts.top < 1 {
+		return errors.New(fmt.Sprintf("Unusable --top: %d, it should be at least 1", opts.top))
+	}
+	if opts.wall < 1 {
+		return errors.New(fmt.Sprintf("Unusable --wall-of-text: %d, it should be at least 1", opts.wall))
 	}
 
-	hour, hourly_messages := h.get_chattiest_hour()
-	for user, _:= range hour {
-		fmt.Printf("%s sends the most messages during the %dth hour of the day, %d all told!\n", user, hour[user], hourly_messages[user])
+	var format chat.Format
+	if opts.format == "auto" {
+		format, err = chat.Detect(filename)
+	} else {
+		format, err = chat.Lookup(opts.format)
+	}
+	if err != nil {
+		return err
 	}
 
+	fmt.Printf("Beginning analysis of %s (%s) ...\n", filename, format.Name)
 
-	h.write_weekday_csv()
-	h.write_hourly_csv()
-	h.write_alltime_csv()
-}
+	histo := stats.NewHistogram(location, opts.userZones)
+	histo.WeekStart = week_start
 
-func (m Message) display() {
-	fmt.Println(m.Day, m.Month, m.Year, m.Hour, m.Minute, m.Second, m.User, m.Body)
-	return
-}
+	rejects := new(rejectLog)
 
-func Analyze() error {
-	filename, err := parseArgs()
+	replies := stats.NewReplyTimes(location, opts.userZones, opts.cutoff)
 
-	if err != nil {
-		return err
-	}
+	sessions := stats.NewSessions(location, opts.userZones, opts.gap)
+	sessions.WeekStart = week_start
 
-	// Make sure the file exists
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		return errors.New(fmt.Sprintf("Unable to open file: %s", filename))
-	}
+	words := stats.NewWordCounts(stop_words)
+
+	emoji := stats.NewEmojiCounts(location)
 
-	fmt.Printf("Beginning analysis of %s ...\n", filename)
+	lengths := stats.NewMessageLengths(location, opts.wall)
 
-	f, err := os.Open(filename)
+	results := analysis{histo: histo, replies: replies, sessions: sessions, words: words, emoji: emoji, lengths: lengths, top: opts.top}
 
+	source, err := format.ParseFile(filename, location, results.feed, rejects.add)
 	if err != nil {
-		return errors.New(fmt.Sprintf("Unable to access file: %s", filename))
+		return err
 	}
 
-	// make sure the file gets closed when we exit
-	defer f.Close()
-
-	histo := new(Histogram)
-	histo.init()
-
-	scanner := bufio.NewScanner(f)
-	line_count := 0
-	for scanner.Scan() {
-		line_count++
-		message := new(Message)
+	out := outputs{dir: opts.outDir, prefix: opts.prefix, noClobber: opts.noClobber}
+	out.csv = stats.CSVOptions{Comma: delimiter, BOM: opts.bom}
+	if out.prefix == "" {
+		out.prefix = outputPrefix(source.Name, filename)
+	}
 
-		line := scanner.Text()
+	if opts.rejects != "" {
+		if err := out.writeFile(opts.rejects, rejects.write); err != nil {
+			return err
+		}
+	}
 
-		// parse out the date and time
-		fmt.Sscanf(line, "%d.%d.%d %d:%d:%d\n", &message.Day, &message.Month, &message.Year, &message.Hour, &message.Minute, &message.Second)
+	histo.Report(os.Stdout)
+	replies.Report(os.Stdout)
+	sessions.Report(os.Stdout)
+	sessions.ReportInitiative(os.Stdout)
+	words.Report(os.Stdout, opts.top)
+	emoji.Report(os.Stdout, opts.top)
+	lengths.Report(os.Stdout)
+
+	if opts.charts {
+		style := terminalStyle()
+		fmt.Println()
+		for _, c := range []chart.Chart{hourChart(histo), weekdayChart(histo), weeklyChart(histo)} {
+			if err := c.WriteText(os.Stdout, style); err != nil {
+				return err
+			}
+		}
+		if err := heatmapChart(histo).WriteText(os.Stdout, style); err != nil {
+			return err
+		}
+	}
 
-		separated := strings.SplitN(line, ",", 2)
-		// looks like a safe assumption that correctly formatted records have a comma between the datestamp and the data
-		if len(separated) < 2 {
-			fmt.Printf("Warning: discarding malformed record on line: %d\n", line_count)
-			fmt.Printf("\t%s\n", line)
+	for _, file := range results.csvFiles(resolutions) {
+		if file.analysis != "" && !csv_analyses[file.analysis] {
 			continue
-		} else {
-			// extract username and message body, again presence of colon seems like a safe assumption
-			userbody := strings.SplitN(separated[1], ":", 2)
-			if len(userbody) < 2 {
-				fmt.Println("Warning: discarding malformed record.")
-				fmt.Printf("\t%s\n", line)
-				continue
+		}
+		if err := out.writeCSV(file.name, file.write); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range charts(histo, chart_width, chart_height) {
+		if opts.svg {
+			if err := out.write(c.name+".svg", c.chart.WriteSVG); err != nil {
+				return err
 			}
-			message.User = userbody[0]
-			message.Body = userbody[1]
 		}
-		histo.count(message)
+		if opts.png {
+			if err := out.write(c.name+".png", c.chart.WritePNG); err != nil {
+				return err
+			}
+		}
+	}
+
+	doc := newDocument(filename, source, results)
+	if opts.json != "" {
+		if err := out.writeFile(opts.json, doc.write); err != nil {
+			return err
+		}
+	}
+	if opts.html != "" {
+		if err := out.writeFile(opts.html, doc.writeHTML); err != nil {
+			return err
+		}
 	}
 
-	histo.report()
+	rejects.report(opts.rejects != "")
 	return nil
 }
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/rsalmond/kissyface/chart"
+	"github.com/rsalmond/kissyface/stats"
+	"io"
+	"time"
+)
+
+// picture is a chart that can be saved as an image
+type picture interface {
+	WriteSVG(io.Writer) error
+	WritePNG(io.Writer) error
+}
+
+// namedChart is one of the histograms drawn as a picture, with the output filename (less its extension) it's saved as
+type namedChart struct {
+	name  string
+	chart picture
+}
+
+// charts draws every histogram width pixels across and, where the chart doesn't decide for itself, height high
+func charts(histo *stats.Histogram, width int, height int) []namedChart {
+	sized := func(c chart.Chart) chart.Chart {
+		c.Width, c.Height = width, height
+		return c
+	}
+
+	heatmap := heatmapChart(histo)
+	heatmap.Width = width
+
+	return []namedChart{
+		{"weekday", sized(weekdayChart(histo))},
+		{"hourly", sized(hourChart(histo))},
+		{"heatmap", heatmap},
+		{"all_time", sized(timelineChart(histo))},
+	}
+}
+
+func hourChart(histo *stats.Histogram) chart.Chart {
+	c := chart.Chart{Title: "Messages by hour of the day"}
+	for hour := 0; hour < 24; hour++ {
+		c.Labels = append(c.Labels, fmt.Sprintf("%02d", hour))
+	}
+	for _, user := range histo.UserNames() {
+		series := chart.Series{Name: user}
+		for hour := 0; hour < 24; hour++ {
+			series.Values = append(series.Values, histo.ByHour(hour, user))
+		}
+		c.Series = append(c.Series, series)
+	}
+	return c
+}
+
+func weekdayChart(histo *stats.Histogram) chart.Chart {
+	c := chart.Chart{Title: "Messages by day of the week"}
+	for _, day := range histo.WeekdayOrder() {
+		c.Labels = append(c.Labels, day.String())
+	}
+	for _, user := range histo.UserNames() {
+		series := chart.Series{Name: user}
+		for _, day := range histo.WeekdayOrder() {
+			series.Values = append(series.Values, histo.ByWeekday(day, user))
+		}
+		c.Series = append(c.Series, series)
+	}
+	return c
+}
+
+// heatmapChart shows each user's hours of the day across and days of the week down
+func heatmapChart(histo *stats.Histogram) chart.Heatmap {
+	h := chart.Heatmap{Title: "Messages by day of the week and hour of the day"}
+	for _, day := range histo.WeekdayOrder() {
+		h.Rows = append(h.Rows, day.String()[:3])
+	}
+	for hour := 0; hour < 24; hour++ {
+		h.Columns = append(h.Columns, fmt.Sprintf("%02d", hour))
+	}
+
+	for _, user := range histo.UserNames() {
+		series := chart.HeatmapSeries{Name: user}
+		for _, day := range histo.WeekdayOrder() {
+			hours := make([]int, 24)
+			for hour := range hours {
+				hours[hour] = histo.ByWeekdayHour(day, hour, user)
+			}
+			series.Values = append(series.Values, hours)
+		}
+		h.Series = append(h.Series, series)
+	}
+	return h
+}
+
+// timelineChart adds the all time series up by day, or by week for chats that go back more than a couple of years,
+// hourly is far too fine to make anything out
+func timelineChart(histo *stats.Histogram) chart.Chart {
+	if histo.TotalMessages > 0 && histo.Last.Sub(histo.First) > 2*365*24*time.Hour {
+		return weeklyChart(histo)
+	}
+	return seriesChart(histo, "Messages per day", stats.PerDay)
+}
+
+func weeklyChart(histo *stats.Histogram) chart.Chart {
+	return seriesChart(histo, "Messages per week", stats.PerWeek)
+}
+
+func seriesChart(histo *stats.Histogram, title string, r stats.Resolution) chart.Chart {
+	c := chart.Chart{Title: title, Lines: true}
+
+	buckets := histo.Series(r)
+	for _, b := range buckets {
+		c.Labels = append(c.Labels, b.Label)
+	}
+	for _, user := range histo.UserNames() {
+		series := chart.Series{Name: user}
+		for _, b := range buckets {
+			series.Values = append(series.Values, b.Counts[user])
+		}
+		c.Series = append(c.Series, series)
+	}
+	return c
+}
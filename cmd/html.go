@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"github.com/rsalmond/kissyface/stats"
+	"html/template"
+	"io"
+)
+
+// the report is a single page with everything inlined, so it can be emailed around and opened without a network
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>kissyface{{if .Meta.Chat}}: {{.Meta.Chat}}{{end}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #333; max-width: 960px; margin: 2em auto; padding: 0 1em; }
+h1 { font-weight: normal; }
+h2 { font-weight: normal; border-bottom: 1px solid #ddd; padding-bottom: .2em; margin-top: 2em; }
+table { border-collapse: collapse; }
+td, th { padding: .3em 1em .3em 0; text-align: left; }
+td.number { text-align: right; }
+.meta { color: #888; font-size: .9em; }
+.legend span { display: inline-block; margin-right: 1.5em; }
+.legend i { display: inline-block; width: .8em; height: .8em; margin-right: .4em; }
+svg { width: 100%; height: auto; }
+svg text { font-size: 11px; fill: #666; }
+svg .axis { stroke: #ccc; }
+</style>
+</head>
+<body>
+<h1>😘 {{if .Meta.Chat}}{{.Meta.Chat}}{{else}}{{.Meta.Input}}{{end}}</h1>
+<p class="meta">
+{{.TotalMessages}} messages{{if .Meta.FirstMessage}} from {{.Meta.FirstMessage.Format "2 January 2006"}} to {{.Meta.LastMessage.Format "2 January 2006"}}{{end}},
+read from {{.Meta.Input}} ({{.Meta.Format}}), times in {{.Meta.TimeZone}}{{range $user, $zone := .Meta.UserTimeZones}}, {{$user}}'s in {{$zone}}{{end}}.
+</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th></th><th>Messages</th><th>Chattiest day</th><th>Chattiest hour</th></tr>
+{{range .Users}}<tr>
+<td>{{.Name}}</td>
+<td class="number">{{.Messages}}</td>
+<td>{{.ChattiestDay}} ({{.ChattiestDayMessages}})</td>
+<td>{{printf "%02d:00" .ChattiestHour}} ({{.ChattiestHourMessages}})</td>
+</tr>
+{{end}}</table>
+
+<div class="legend" id="legend"></div>
+
+<h2>Messages by hour of the day</h2>
+<svg id="hours" viewBox="0 0 960 300"></svg>
+
+<h2>Messages by day of the week</h2>
+<svg id="weekdays" viewBox="0 0 960 300"></svg>
+
+<h2>Messages over time</h2>
+<svg id="timeline" viewBox="0 0 960 300"></svg>
+
+<script>
+var analysis = {{.Charts}};
+
+(function() {
+	var svgns = "http://www.w3.org/2000/svg";
+	var colours = ["#e6194b", "#4363d8", "#3cb44b", "#f58231", "#911eb4", "#42d4f4", "#f032e6", "#9a6324"];
+	var users = analysis.users;
+	var width = 960, height = 300, left = 50, right = 10, top = 10, bottom = 40;
+
+	function el(parent, name, attrs, text) {
+		var e = document.createElementNS(svgns, name);
+		for (var a in attrs) { e.setAttribute(a, attrs[a]); }
+		if (text !== undefined) { e.textContent = text; }
+		parent.appendChild(e);
+		return e;
+	}
+
+	// a handful of round numbers up the y axis
+	function axis(svg, max) {
+		var step = Math.pow(10, Math.floor(Math.log(Math.max(max, 1)) / Math.LN10));
+		if (max / step > 5) { step *= 2; }
+		for (var v = 0; v <= max; v += step) {
+			var y = height - bottom - v / max * (height - top - bottom);
+			el(svg, "line", {x1: left, x2: width - right, y1: y, y2: y, "class": "axis"});
+			el(svg, "text", {x: left - 6, y: y + 4, "text-anchor": "end"}, v);
+		}
+	}
+
+	function bars(id, buckets) {
+		var svg = document.getElementById(id);
+		var max = 1;
+		buckets.forEach(function(b) { users.forEach(function(u) { max = Math.max(max, b.counts[u]); }); });
+		axis(svg, max);
+
+		var slot = (width - left - right) / buckets.length;
+		var bar = slot * 0.8 / users.length;
+		buckets.forEach(function(b, i) {
+			var x = left + i * slot + slot * 0.1;
+			users.forEach(function(u, j) {
+				var h = b.counts[u] / max * (height - top - bottom);
+				var rect = el(svg, "rect", {x: x + j * bar, y: height - bottom - h, width: bar, height: h, fill: colours[j % colours.length]});
+				el(rect, "title", {}, u + ": " + b.counts[u]);
+			});
+			el(svg, "text", {x: left + (i + 0.5) * slot, y: height - bottom + 16, "text-anchor": "middle"}, b.label);
+		});
+	}
+
+	function timeline(id, series) {
+		var svg = document.getElementById(id);
+		if (series.length === 0) { return; }
+
+		var max = 1;
+		series.forEach(function(s) { users.forEach(function(u) { max = Math.max(max, s.counts[u]); }); });
+		axis(svg, max);
+
+		var step = (width - left - right) / Math.max(series.length - 1, 1);
+		users.forEach(function(u, j) {
+			var points = series.map(function(s, i) {
+				return (left + i * step).toFixed(1) + "," + (height - bottom - s.counts[u] / max * (height - top - bottom)).toFixed(1);
+			});
+			el(svg, "polyline", {points: points.join(" "), fill: "none", stroke: colours[j % colours.length], "stroke-width": 1.5});
+		});
+
+		var labels = Math.min(series.length, 8);
+		for (var i = 0; i < labels; i++) {
+			var n = Math.round(i * (series.length - 1) / Math.max(labels - 1, 1));
+			el(svg, "text", {x: left + n * step, y: height - bottom + 16, "text-anchor": "middle"}, series[n].label);
+		}
+	}
+
+	var legend = document.getElementById("legend");
+	users.forEach(function(u, j) {
+		var span = document.createElement("span");
+		var swatch = document.createElement("i");
+		swatch.style.background = colours[j % colours.length];
+		span.appendChild(swatch);
+		span.appendChild(document.createTextNode(u));
+		legend.appendChild(span);
+	});
+
+	bars("hours", analysis.hours);
+	bars("weekdays", analysis.weekdays);
+	timeline("timeline", analysis.timeline);
+})();
+</script>
+</body>
+</html>
+`))
+
+// the most days the report's timeline plots one by one, longer chats are plotted by the week
+const reportDays = 730
+
+// report is what the report template is filled in with
+type report struct {
+	document
+	// Charts is all the report's script gets, the rest of the document would only bloat the page
+	Charts reportCharts
+}
+
+// reportCharts is the data behind the report's charts
+type reportCharts struct {
+	Users    []string       `json:"users"`
+	Hours    []stats.Bucket `json:"hours"`
+	Weekdays []stats.Bucket `json:"weekdays"`
+	Timeline []stats.Bucket `json:"timeline"`
+}
+
+// writeHTML renders the analysis as a page that anybody can double-click open in a browser
+func (d document) writeHTML(w io.Writer) error {
+	charts := reportCharts{Users: make([]string, 0, len(d.Users)), Hours: d.Hours, Weekdays: d.Weekdays, Timeline: d.AllTimeDaily}
+	for _, user := range d.Users {
+		charts.Users = append(charts.Users, user.Name)
+	}
+	if len(d.AllTimeDaily) > reportDays {
+		charts.Timeline = d.AllTimeWeekly
+	}
+	return reportTemplate.Execute(w, report{document: d, Charts: charts})
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/rsalmond/kissyface/chat"
+	"github.com/rsalmond/kissyface/stats"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// document is everything kissyface found out about a chat, as written by --json
+type document struct {
+	Meta documentMeta `json:"meta"`
+	stats.Summary
+	Replies  stats.ReplySummary   `json:"replies"`
+	Sessions stats.SessionSummary `json:"sessions"`
+	Words    []stats.UserWords    `json:"words"`
+	Emoji    stats.EmojiSummary   `json:"emoji"`
+	Lengths  stats.LengthSummary  `json:"message_lengths"`
+}
+
+// documentMeta records where the analysis came from, so a document makes sense on its own
+type documentMeta struct {
+	Input         string            `json:"input"`
+	Inputs        []string          `json:"inputs"`
+	Format        string            `json:"format"`
+	Chat          string            `json:"chat,omitempty"`
+	TimeZone      string            `json:"time_zone"`
+	UserTimeZones map[string]string `json:"user_time_zones,omitempty"`
+	WeekStart     string            `json:"week_start"`
+	FirstMessage  *time.Time        `json:"first_message,omitempty"`
+	LastMessage   *time.Time        `json:"last_message,omitempty"`
+}
+
+// zoneName names location in a way that still means something on another machine. Go calls the zone of the machine
+// it's running on "Local", so for that one we go looking for its real name where Go found it.
+func zoneName(location *time.Location) string {
+	if location != time.Local {
+		return location.String()
+	}
+
+	// $TZ wins when it's set, otherwise it's /etc/localtime, which is usually a link into the zoneinfo database
+	path := "/etc/localtime"
+	if tz, set := os.LookupEnv("TZ"); set {
+		tz = strings.TrimPrefix(tz, ":")
+		if !filepath.IsAbs(tz) {
+			// Go falls back to UTC when $TZ is empty or isn't a zone it knows
+			if _, err := time.LoadLocation(tz); err != nil || tz == "" {
+				return "UTC"
+			}
+			return tz
+		}
+		path = tz
+	}
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		if i := strings.LastIndex(target, "zoneinfo/"); i >= 0 {
+			return target[i+len("zoneinfo/"):]
+		}
+	}
+
+	// there's no name to be had, today's offset from UTC is better than nothing
+	_, offset := time.Now().In(location).Zone()
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	return fmt.Sprintf("UTC%c%02d:%02d", sign, offset/3600, offset%3600/60)
+}
+
+func newDocument(filename string, source chat.Chat, results analysis) document {
+	histo := results.histo
+	doc := document{
+		Meta: documentMeta{
+			Input:     filename,
+			Inputs:    source.Inputs,
+			Format:    source.Format,
+			Chat:      source.Name,
+			TimeZone:  zoneName(histo.Location),
+			WeekStart: histo.WeekStart.String(),
+		},
+		Summary:  histo.Summary(),
+		Replies:  results.replies.Summary(),
+		Sessions: results.sessions.Summary(),
+		Words:    results.words.Summary(results.top),
+		Emoji:    results.emoji.Summary(results.top),
+		Lengths:  results.lengths.Summary(),
+	}
+
+	if len(histo.UserLocations) > 0 {
+		doc.Meta.UserTimeZones = make(map[string]string, len(histo.UserLocations))
+		for user, location := range histo.UserLocations {
+			doc.Meta.UserTimeZones[user] = zoneName(location)
+		}
+	}
+
+	// an empty chat has no date range at all
+	if histo.TotalMessages > 0 {
+		first, last := histo.First, histo.Last
+		doc.Meta.FirstMessage = &first
+		doc.Meta.LastMessage = &last
+	}
+
+	return doc
+}
+
+func (d document) write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(d)
+}
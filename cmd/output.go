@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/rsalmond/kissyface/stats"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// outputs decides where the files kissyface writes end up, so analyses of several chats can sit side by side
+type outputs struct {
+	dir    string
+	prefix string
+	// rather than overwrite an earlier run's files, number the new ones
+	noClobber bool
+	csv       stats.CSVOptions
+}
+
+// outputPrefix turns the chat's name, or failing that the name of the file it came from, into something safe to
+// start a filename with
+func outputPrefix(chat_name string, filename string) string {
+	name := chat_name
+	if name == "" {
+		name = filepath.Base(filepath.Clean(filename))
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	// anything that isn't a letter or a number becomes an underscore, and runs of them are squashed together
+	prefix := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == '-' {
+			return r
+		}
+		return '_'
+	}, name)
+	for strings.Contains(prefix, "__") {
+		prefix = strings.Replace(prefix, "__", "_", -1)
+	}
+
+	return strings.Trim(prefix, "_")
+}
+
+func (o outputs) path(name string) string {
+	if o.prefix != "" {
+		name = o.prefix + "_" + name
+	}
+	return filepath.Join(o.dir, name)
+}
+
+// create opens a new output file at path, it returns the path it actually used
+func (o outputs) create(path string) (*os.File, string, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, "", errors.Wrap(err, fmt.Sprintf("failed to create output directory %s", filepath.Dir(path)))
+	}
+
+	if !o.noClobber {
+		f, err := os.Create(path)
+		return f, path, err
+	}
+
+	// hourly.csv, then hourly.2.csv, hourly.3.csv and so on
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for version := 1; ; version++ {
+		if version > 1 {
+			path = fmt.Sprintf("%s.%d%s", base, version, ext)
+		}
+
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		return f, path, err
+	}
+}
+
+// write creates the output file called name (in the output directory, after the prefix) and has write fill it in
+func (o outputs) write(name string, write func(io.Writer) error) error {
+	return o.writeFile(o.path(name), write)
+}
+
+// writeFile creates the output file at path and has write fill it in
+func (o outputs) writeFile(path string, write func(io.Writer) error) error {
+	f, path, err := o.create(path)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to write %s", path))
+	}
+
+	err = write(f)
+	// a failed close can be the first we hear of the disk filling up
+	if close_err := f.Close(); err == nil {
+		err = close_err
+	}
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to write %s", path))
+	}
+
+	fmt.Printf("Wrote %s\n", path)
+	return nil
+}
+
+// writeCSV writes a CSV output file called name using the delimiter and BOM settings the user asked for
+func (o outputs) writeCSV(name string, write func(io.Writer, stats.CSVOptions) error) error {
+	return o.write(name, func(w io.Writer) error { return write(w, o.csv) })
+}
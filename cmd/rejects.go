@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/rsalmond/kissyface/chat"
+	"io"
+)
+
+// how many rejected records we show on the console before pointing at --rejects for the rest
+const rejectsShown = 5
+
+// rejectLog collects every piece of the input a parser had to throw away, so we can own up to it at the end
+type rejectLog struct {
+	records []chat.Diagnostic
+}
+
+func (r *rejectLog) add(d chat.Diagnostic) {
+	r.records = append(r.records, d)
+}
+
+// report owns up to the rejected records on the console, saved says whether --rejects wrote them all out
+func (r rejectLog) report(saved bool) {
+	if len(r.records) == 0 {
+		return
+	}
+
+	fmt.Printf("Warning: discarded %d malformed records\n", len(r.records))
+	for i, record := range r.records {
+		if i == rejectsShown {
+			break
+		}
+		fmt.Printf("\t%s\n", record)
+	}
+
+	switch {
+	case saved:
+		fmt.Println("All of them have been written to the --rejects file")
+	case len(r.records) > rejectsShown:
+		fmt.Printf("\t... and %d more, use --rejects <file> to see them all\n", len(r.records)-rejectsShown)
+	}
+}
+
+// write saves every rejected record, one per line, so they can be found and fixed in the input
+func (r rejectLog) write(w io.Writer) error {
+	buffered := bufio.NewWriter(w)
+	for _, record := range r.records {
+		if _, err := fmt.Fprintln(buffered, record); err != nil {
+			return err
+		}
+	}
+	return buffered.Flush()
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/rsalmond/kissyface/chart"
+	"os"
+	"strconv"
+)
+
+// terminalStyle works out what the console can show. Block characters and colours are only used when stdout is a
+// terminal, anything piped into a file or another program gets plain ASCII.
+func terminalStyle() chart.TextStyle {
+	style := chart.TextStyle{Width: 80}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		style.Width = columns
+	}
+
+	info, err := os.Stdout.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return style
+	}
+
+	style.Unicode = true
+	// https://no-color.org
+	style.Colour = os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+	return style
+}
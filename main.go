@@ -1,11 +1,16 @@
 package main
 
 import "fmt"
+import "os"
 import "github.com/rsalmond/kissyface/cmd"
 
 func main() {
-	err := cmd.Analyze()
+	err := cmd.Analyze(os.Args)
 
+	if err == cmd.ErrUsage {
+		// the usage has been printed already, exit the way the flag package does
+		os.Exit(2)
+	}
 	if err != nil {
 		fmt.Println(err)
 	}
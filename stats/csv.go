@@ -0,0 +1,59 @@
+package stats
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// CSVOptions controls how CSV files are written
+type CSVOptions struct {
+	// Comma separates the fields in a row, ',' unless it's set otherwise (eg. ';' for Excel in much of Europe)
+	Comma rune
+	// BOM starts the file with a UTF-8 byte order mark, without it Excel reads anybody's name with an accent in it
+	// as gibberish
+	BOM bool
+}
+
+// csvWriter writes RFC 4180 rows, quoting anything that needs it and holding on to the first error it runs into
+type csvWriter struct {
+	w   *csv.Writer
+	err error
+}
+
+func newCSVWriter(w io.Writer, opts CSVOptions) *csvWriter {
+	c := &csvWriter{w: csv.NewWriter(w)}
+	if opts.Comma != 0 {
+		c.w.Comma = opts.Comma
+	}
+	if opts.BOM {
+		_, c.err = io.WriteString(w, "\uFEFF")
+	}
+	return c
+}
+
+// write adds a row made up of label followed by counts
+func (c *csvWriter) write(label string, counts ...int) {
+	row := make([]string, 0, len(counts)+1)
+	row = append(row, label)
+	for _, count := range counts {
+		row = append(row, strconv.Itoa(count))
+	}
+	c.writeStrings(row)
+}
+
+func (c *csvWriter) writeStrings(row []string) {
+	if c.err != nil {
+		return
+	}
+	c.err = c.w.Write(row)
+}
+
+// close flushes everything written so far and reports the first thing that went wrong along the way
+func (c *csvWriter) close() error {
+	if c.err != nil {
+		return c.err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
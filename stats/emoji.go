@@ -0,0 +1,532 @@
+package stats
+
+import (
+	"fmt"
+	"github.com/rsalmond/kissyface/chat"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// the code points emoji sequences are glued together with
+const (
+	zeroWidthJoiner    = '\u200D'
+	emojiPresentation  = '\uFE0F'
+	combiningKeycap    = '\u20E3'
+	cancelTag          = '\U000E007F'
+	regionalIndicatorA = '\U0001F1E6'
+	regionalIndicatorZ = '\U0001F1FF'
+)
+
+// pictographs are Unicode's Extended_Pictographic code points, everything that can be drawn as an emoji. Most of the
+// older ones, like ❤ and ★, are plain text unless a variation selector asks for an emoji.
+var pictographs = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x00A9, 0x00AE, 5},
+		{0x203C, 0x2049, 13},
+		{0x2122, 0x2139, 23},
+		{0x2194, 0x2199, 1},
+		{0x21A9, 0x21AA, 1},
+		{0x231A, 0x231B, 1},
+		{0x2328, 0x2388, 96},
+		{0x23CF, 0x23CF, 1},
+		{0x23E9, 0x23F3, 1},
+		{0x23F8, 0x23FA, 1},
+		{0x24C2, 0x24C2, 1},
+		{0x25AA, 0x25AB, 1},
+		{0x25B6, 0x25C0, 10},
+		{0x25FB, 0x25FE, 1},
+		{0x2600, 0x2605, 1},
+		{0x2607, 0x2612, 1},
+		{0x2614, 0x2685, 1},
+		{0x2690, 0x2705, 1},
+		{0x2708, 0x2712, 1},
+		{0x2714, 0x2716, 2},
+		{0x271D, 0x2721, 4},
+		{0x2728, 0x2728, 1},
+		{0x2733, 0x2734, 1},
+		{0x2744, 0x2747, 3},
+		{0x274C, 0x274E, 2},
+		{0x2753, 0x2755, 1},
+		{0x2757, 0x2757, 1},
+		{0x2763, 0x2767, 1},
+		{0x2795, 0x2797, 1},
+		{0x27A1, 0x27BF, 15},
+		{0x2934, 0x2935, 1},
+		{0x2B05, 0x2B07, 1},
+		{0x2B1B, 0x2B1C, 1},
+		{0x2B50, 0x2B55, 5},
+		{0x3030, 0x303D, 13},
+		{0x3297, 0x3299, 2},
+	},
+	R32: []unicode.Range32{
+		{0x1F000, 0x1F0FF, 1},
+		{0x1F10D, 0x1F10F, 1},
+		{0x1F12F, 0x1F12F, 1},
+		{0x1F16C, 0x1F171, 1},
+		{0x1F17E, 0x1F17F, 1},
+		{0x1F18E, 0x1F18E, 1},
+		{0x1F191, 0x1F19A, 1},
+		{0x1F1AD, 0x1F1E5, 1},
+		{0x1F201, 0x1F20F, 1},
+		{0x1F21A, 0x1F22F, 21},
+		{0x1F232, 0x1F23A, 1},
+		{0x1F23C, 0x1F23F, 1},
+		{0x1F249, 0x1F3FA, 1},
+		{0x1F400, 0x1F53D, 1},
+		{0x1F546, 0x1F64F, 1},
+		{0x1F680, 0x1F6FF, 1},
+		{0x1F774, 0x1F77F, 1},
+		{0x1F7D5, 0x1F7FF, 1},
+		{0x1F80C, 0x1F80F, 1},
+		{0x1F848, 0x1F84F, 1},
+		{0x1F85A, 0x1F85F, 1},
+		{0x1F888, 0x1F88F, 1},
+		{0x1F8AE, 0x1F8FF, 1},
+		{0x1F90C, 0x1F93A, 1},
+		{0x1F93C, 0x1F945, 1},
+		{0x1F947, 0x1FAFF, 1},
+		{0x1FC00, 0x1FFFD, 1},
+	},
+	LatinOffset: 1,
+}
+
+// defaultEmoji are the pictographs with Unicode's Emoji_Presentation property, the ones that are drawn as emoji even
+// without a variation selector
+var defaultEmoji = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x231A, 0x231B, 1},
+		{0x23E9, 0x23EC, 1},
+		{0x23F0, 0x23F3, 3},
+		{0x25FD, 0x25FE, 1},
+		{0x2614, 0x2615, 1},
+		{0x2648, 0x2653, 1},
+		{0x267F, 0x2693, 20},
+		{0x26A1, 0x26A1, 1},
+		{0x26AA, 0x26AB, 1},
+		{0x26BD, 0x26BE, 1},
+		{0x26C4, 0x26C5, 1},
+		{0x26CE, 0x26D4, 6},
+		{0x26EA, 0x26EA, 1},
+		{0x26F2, 0x26F3, 1},
+		{0x26F5, 0x26FA, 5},
+		{0x26FD, 0x2705, 8},
+		{0x270A, 0x270B, 1},
+		{0x2728, 0x274C, 36},
+		{0x274E, 0x274E, 1},
+		{0x2753, 0x2755, 1},
+		{0x2757, 0x2757, 1},
+		{0x2795, 0x2797, 1},
+		{0x27B0, 0x27BF, 15},
+		{0x2B1B, 0x2B1C, 1},
+		{0x2B50, 0x2B55, 5},
+	},
+	R32: []unicode.Range32{
+		{0x1F004, 0x1F0CF, 203},
+		{0x1F18E, 0x1F18E, 1},
+		{0x1F191, 0x1F19A, 1},
+		{0x1F1E6, 0x1F1FF, 1},
+		{0x1F201, 0x1F21A, 25},
+		{0x1F22F, 0x1F22F, 1},
+		{0x1F232, 0x1F236, 1},
+		{0x1F238, 0x1F23A, 1},
+		{0x1F250, 0x1F251, 1},
+		{0x1F300, 0x1F320, 1},
+		{0x1F32D, 0x1F335, 1},
+		{0x1F337, 0x1F37C, 1},
+		{0x1F37E, 0x1F393, 1},
+		{0x1F3A0, 0x1F3CA, 1},
+		{0x1F3CF, 0x1F3D3, 1},
+		{0x1F3E0, 0x1F3F0, 1},
+		{0x1F3F4, 0x1F3F4, 1},
+		{0x1F3F8, 0x1F43E, 1},
+		{0x1F440, 0x1F440, 1},
+		{0x1F442, 0x1F4FC, 1},
+		{0x1F4FF, 0x1F53D, 1},
+		{0x1F54B, 0x1F54E, 1},
+		{0x1F550, 0x1F567, 1},
+		{0x1F57A, 0x1F57A, 1},
+		{0x1F595, 0x1F596, 1},
+		{0x1F5A4, 0x1F5A4, 1},
+		{0x1F5FB, 0x1F64F, 1},
+		{0x1F680, 0x1F6C5, 1},
+		{0x1F6CC, 0x1F6CC, 1},
+		{0x1F6D0, 0x1F6D2, 1},
+		{0x1F6D5, 0x1F6D7, 1},
+		{0x1F6DC, 0x1F6DF, 1},
+		{0x1F6EB, 0x1F6EC, 1},
+		{0x1F6F4, 0x1F6FC, 1},
+		{0x1F7E0, 0x1F7EB, 1},
+		{0x1F7F0, 0x1F7F0, 1},
+		{0x1F90C, 0x1F93A, 1},
+		{0x1F93C, 0x1F945, 1},
+		{0x1F947, 0x1F9FF, 1},
+		{0x1FA70, 0x1FA7C, 1},
+		{0x1FA80, 0x1FA89, 1},
+		{0x1FA8F, 0x1FAC6, 1},
+		{0x1FACE, 0x1FADC, 1},
+		{0x1FADF, 0x1FAE9, 1},
+		{0x1FAF0, 0x1FAF8, 1},
+	},
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= regionalIndicatorA && r <= regionalIndicatorZ
+}
+
+func isSkinTone(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
+
+func isTag(r rune) bool {
+	return r >= 0xE0020 && r <= cancelTag
+}
+
+func isKeycapBase(r rune) bool {
+	return (r >= '0' && r <= '9') || r == '#' || r == '*'
+}
+
+// startsEmoji says whether the emoji starts at runes[i]
+func startsEmoji(runes []rune, i int) bool {
+	r := runes[i]
+	if isSkinTone(r) || isRegionalIndicator(r) {
+		return false
+	}
+	if unicode.Is(defaultEmoji, r) {
+		return true
+	}
+	return unicode.Is(pictographs, r) && i+1 < len(runes) && runes[i+1] == emojiPresentation
+}
+
+// Emoji picks out every emoji in text, in order. Sequences that are drawn as a single emoji, like families joined
+// with zero width joiners, skin tones, flags and keycaps, are kept whole.
+func Emoji(text string) []string {
+	runes := []rune(text)
+	emoji := make([]string, 0)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case isRegionalIndicator(r) && i+1 < len(runes) && isRegionalIndicator(runes[i+1]):
+			// a pair of regional indicators is a country's flag
+			emoji = append(emoji, string(runes[i:i+2]))
+			i += 2
+
+		case isKeycapBase(r):
+			end := i + 1
+			if end < len(runes) && runes[end] == emojiPresentation {
+				end++
+			}
+			if end < len(runes) && runes[end] == combiningKeycap {
+				emoji = append(emoji, normalizeEmoji(runes[i:end+1]))
+				i = end + 1
+				continue
+			}
+			i++
+
+		case startsEmoji(runes, i):
+			end := i + 1
+			for {
+				if end < len(runes) && runes[end] == emojiPresentation {
+					end++
+				}
+				if end < len(runes) && isSkinTone(runes[end]) {
+					end++
+				}
+				// the flags of England, Scotland and Wales are spelled out in tags after a black flag
+				for end < len(runes) && isTag(runes[end]) {
+					end++
+				}
+				if end+1 < len(runes) && runes[end] == zeroWidthJoiner && unicode.Is(pictographs, runes[end+1]) {
+					end += 2
+					continue
+				}
+				break
+			}
+			emoji = append(emoji, normalizeEmoji(runes[i:end]))
+			i = end
+
+		default:
+			i++
+		}
+	}
+	return emoji
+}
+
+// normalizeEmoji makes the same emoji come out the same however it was typed. Some keyboards put variation selectors
+// after emoji that are drawn as emoji anyway and those are dropped, the ones after text symbols and keycaps are left
+// as they were typed.
+func normalizeEmoji(sequence []rune) string {
+	for _, r := range sequence {
+		if r == zeroWidthJoiner {
+			// joined sequences are only ever written one way
+			return string(sequence)
+		}
+	}
+
+	runes := make([]rune, 0, len(sequence))
+	for i, r := range sequence {
+		if r == emojiPresentation && i > 0 && unicode.Is(defaultEmoji, sequence[i-1]) {
+			continue
+		}
+		runes = append(runes, r)
+	}
+	return string(runes)
+}
+
+// EmojiCounts counts the emoji everybody uses, and how their use changes from month to month
+type EmojiCounts struct {
+	// Location is the time zone messages are put into months in
+	Location *time.Location
+	// Counts are how many times each user used each emoji
+	Counts map[string]map[string]int
+	// Messages are how many messages each user sent
+	Messages map[string]int
+	// Months are how many times each user used each emoji, and MonthlyMessages how many messages they sent, in each
+	// month, keyed by the unix time the month starts at
+	Months          map[int64]map[string]map[string]int
+	MonthlyMessages map[int64]map[string]int
+	// First and Last are when the earliest and latest messages were sent
+	First time.Time
+	Last  time.Time
+}
+
+// NewEmojiCounts prepares to count emoji in chat logs with timestamps written in location
+func NewEmojiCounts(location *time.Location) *EmojiCounts {
+	c := new(EmojiCounts)
+	c.Location = location
+	c.Counts = make(map[string]map[string]int, 2)
+	c.Messages = make(map[string]int, 2)
+	c.Months = make(map[int64]map[string]map[string]int)
+	c.MonthlyMessages = make(map[int64]map[string]int)
+	return c
+}
+
+// Feed counts the emoji in a single message
+func (c *EmojiCounts) Feed(m chat.Message) {
+	sent := m.Time.In(c.Location)
+	if c.First.IsZero() || sent.Before(c.First) {
+		c.First = sent
+	}
+	if c.Last.IsZero() || sent.After(c.Last) {
+		c.Last = sent
+	}
+
+	month := PerMonth.start(sent).Unix()
+	if _, present := c.Months[month]; !present {
+		c.Months[month] = make(map[string]map[string]int, 2)
+		c.MonthlyMessages[month] = make(map[string]int, 2)
+	}
+	if _, present := c.Months[month][m.User]; !present {
+		c.Months[month][m.User] = make(map[string]int)
+	}
+	if _, present := c.Counts[m.User]; !present {
+		c.Counts[m.User] = make(map[string]int)
+	}
+
+	c.Messages[m.User]++
+	c.MonthlyMessages[month][m.User]++
+	for _, emoji := range Emoji(m.Body) {
+		c.Counts[m.User][emoji]++
+		c.Months[month][m.User][emoji]++
+	}
+}
+
+// UserNames returns everyone who sent a message, in alphabetical order
+func (c EmojiCounts) UserNames() []string {
+	usernames := make([]string, 0, len(c.Messages))
+	for user := range c.Messages {
+		usernames = append(usernames, user)
+	}
+	sort.Strings(usernames)
+	return usernames
+}
+
+func sumCounts(counts map[string]int) int {
+	sum := 0
+	for _, count := range counts {
+		sum += count
+	}
+	return sum
+}
+
+// Total returns how many emoji user used
+func (c EmojiCounts) Total(user string) int {
+	return sumCounts(c.Counts[user])
+}
+
+// PerMessage returns how many emoji user uses in a message on average
+func (c EmojiCounts) PerMessage(user string) float64 {
+	if c.Messages[user] == 0 {
+		return 0
+	}
+	return float64(c.Total(user)) / float64(c.Messages[user])
+}
+
+// mostUsed returns the n most used emoji in counts, ties in code point order
+func mostUsed(counts map[string]int, n int) []WordCount {
+	emoji := make([]WordCount, 0, len(counts))
+	for e, count := range counts {
+		emoji = append(emoji, WordCount{Word: e, Count: count})
+	}
+	sortWordCounts(emoji)
+	if len(emoji) > n {
+		emoji = emoji[:n]
+	}
+	return emoji
+}
+
+// Top returns the n emoji user used most
+func (c EmojiCounts) Top(user string, n int) []EmojiCount {
+	emoji := make([]EmojiCount, 0, n)
+	for _, e := range mostUsed(c.Counts[user], n) {
+		emoji = append(emoji, EmojiCount{Emoji: e.Word, Count: e.Count})
+	}
+	return emoji
+}
+
+// EmojiCount is how many times an emoji was used
+type EmojiCount struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+}
+
+// EmojiMonth is how many emoji each user used in one month, and which emoji everybody used most
+type EmojiMonth struct {
+	Label      string             `json:"label"`
+	Counts     map[string]int     `json:"counts"`
+	PerMessage map[string]float64 `json:"per_message"`
+	Top        string             `json:"top,omitempty"`
+}
+
+// Monthly returns how much everybody used emoji in every month from the first message to the last
+func (c EmojiCounts) Monthly() []EmojiMonth {
+	months := make([]EmojiMonth, 0)
+	if c.First.IsZero() {
+		return months
+	}
+
+	usernames := c.UserNames()
+	for month := PerMonth.start(c.First); !month.After(c.Last); month = PerMonth.next(month) {
+		m := EmojiMonth{
+			Label:      PerMonth.label(month),
+			Counts:     make(map[string]int, len(usernames)),
+			PerMessage: make(map[string]float64, len(usernames)),
+		}
+
+		everyone := make(map[string]int)
+		for _, user := range usernames {
+			counts := c.Months[month.Unix()][user]
+			m.Counts[user] = sumCounts(counts)
+			if messages := c.MonthlyMessages[month.Unix()][user]; messages > 0 {
+				m.PerMessage[user] = float64(m.Counts[user]) / float64(messages)
+			} else {
+				m.PerMessage[user] = 0
+			}
+			for emoji, count := range counts {
+				everyone[emoji] += count
+			}
+		}
+		if favourite := mostUsed(everyone, 1); len(favourite) > 0 {
+			m.Top = favourite[0].Word
+		}
+
+		months = append(months, m)
+	}
+	return months
+}
+
+// Report writes how much everybody uses emoji, and their favourites, in plain English, listing n of them at most
+func (c EmojiCounts) Report(w io.Writer, n int) {
+	for _, user := range c.UserNames() {
+		if c.Total(user) == 0 {
+			fmt.Fprintf(w, "%s never used an emoji.\n", user)
+			continue
+		}
+
+		favourites := make([]string, 0, n)
+		for _, emoji := range c.Top(user, n) {
+			favourites = append(favourites, fmt.Sprintf("%s (%d)", emoji.Emoji, emoji.Count))
+		}
+		fmt.Fprintf(w, "%s used %d emoji, %.2f a message. Their favourites are %s.\n",
+			user, c.Total(user), c.PerMessage(user), strings.Join(favourites, ", "))
+	}
+}
+
+// WriteCSV writes how many times each user used every emoji, the most used first
+func (c EmojiCounts) WriteCSV(f io.Writer, opts CSVOptions) error {
+	usernames := c.UserNames()
+	everyone := make(map[string]int)
+	for _, counts := range c.Counts {
+		for emoji, count := range counts {
+			everyone[emoji] += count
+		}
+	}
+
+	w := newCSVWriter(f, opts)
+	header := append([]string{"Emoji"}, usernames...)
+	w.writeStrings(append(header, "Total"))
+	for _, emoji := range mostUsed(everyone, len(everyone)) {
+		row := make([]int, 0, len(usernames)+1)
+		for _, user := range usernames {
+			row = append(row, c.Counts[user][emoji.Word])
+		}
+		w.write(emoji.Word, append(row, emoji.Count)...)
+	}
+	return w.close()
+}
+
+// WriteMonthlyCSV writes how many emoji each user used, and how many a message, in every month from the first message
+// to the last, along with the emoji everybody used most that month
+func (c EmojiCounts) WriteMonthlyCSV(f io.Writer, opts CSVOptions) error {
+	usernames := c.UserNames()
+
+	w := newCSVWriter(f, opts)
+	header := []string{"Month"}
+	for _, user := range usernames {
+		header = append(header, user+" emoji", user+" per message")
+	}
+	w.writeStrings(append(header, "Top emoji"))
+
+	for _, month := range c.Monthly() {
+		row := []string{month.Label}
+		for _, user := range usernames {
+			row = append(row, strconv.Itoa(month.Counts[user]), strconv.FormatFloat(month.PerMessage[user], 'f', 2, 64))
+		}
+		w.writeStrings(append(row, month.Top))
+	}
+	return w.close()
+}
+
+// UserEmoji is how much one user uses emoji, and the ones they use most
+type UserEmoji struct {
+	Name       string       `json:"name"`
+	Emoji      int          `json:"emoji"`
+	Messages   int          `json:"messages"`
+	PerMessage float64      `json:"per_message"`
+	Top        []EmojiCount `json:"top"`
+}
+
+// EmojiSummary is everything EmojiCounts found out, laid out to be marshalled to JSON
+type EmojiSummary struct {
+	Users   []UserEmoji  `json:"users"`
+	Monthly []EmojiMonth `json:"monthly"`
+}
+
+// Summary gathers up everyone's emoji, listing n of each user's favourites at most
+func (c EmojiCounts) Summary(n int) EmojiSummary {
+	s := EmojiSummary{Users: make([]UserEmoji, 0, len(c.Messages)), Monthly: c.Monthly()}
+	for _, user := range c.UserNames() {
+		s.Users = append(s.Users, UserEmoji{
+			Name:       user,
+			Emoji:      c.Total(user),
+			Messages:   c.Messages[user],
+			PerMessage: c.PerMessage(user),
+			Top:        c.Top(user, n),
+		})
+	}
+	return s
+}
@@ -0,0 +1,40 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEmoji(t *testing.T) {
+	cases := []struct {
+		name  string
+		text  string
+		emoji []string
+	}{
+		{"none", "just words, © 2018 and 123 #1", []string{}},
+		{"one", "hi \U0001F60D", []string{"\U0001F60D"}},
+		{"repeated", "\U0001F60D\U0001F60D", []string{"\U0001F60D", "\U0001F60D"}},
+		{"family joined with zero width joiners", "\U0001F468\u200D\U0001F469\u200D\U0001F467", []string{"\U0001F468\u200D\U0001F469\u200D\U0001F467"}},
+		{"skin tone", "\U0001F44D\U0001F3FD", []string{"\U0001F44D\U0001F3FD"}},
+		{"skin tone in a joined sequence", "\U0001F469\U0001F3FE\u200D\U0001F4BB", []string{"\U0001F469\U0001F3FE\u200D\U0001F4BB"}},
+		{"flag", "\U0001F1EC\U0001F1E7", []string{"\U0001F1EC\U0001F1E7"}},
+		{"two flags side by side", "\U0001F1EC\U0001F1E7\U0001F1EB\U0001F1F7", []string{"\U0001F1EC\U0001F1E7", "\U0001F1EB\U0001F1F7"}},
+		{"a regional indicator on its own", "\U0001F1EC", []string{}},
+		{"keycap", "1\uFE0F\u20E3", []string{"1\uFE0F\u20E3"}},
+		{"keycap without a variation selector", "#\u20E3", []string{"#\u20E3"}},
+		{"tag sequence", "\U0001F3F4\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F", []string{"\U0001F3F4\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F"}},
+		{"rainbow flag", "\U0001F3F3\uFE0F\u200D\U0001F308", []string{"\U0001F3F3\uFE0F\u200D\U0001F308"}},
+		{"heart with and without a variation selector", "\u2764 \u2764\uFE0F", []string{"\u2764\uFE0F"}},
+		{"emoji with a variation selector it doesn't need", "\U0001F60D\uFE0F \U0001F60D", []string{"\U0001F60D", "\U0001F60D"}},
+		{"text symbols", "\u2713 done \u2605 \u279C next \u2776 \U0001F130", []string{}},
+		{"text symbols with variation selectors", "\u2713\uFE0F \u2605\uFE0F", []string{"\u2605\uFE0F"}},
+		{"text symbol asked to be an emoji", "\u00A9\uFE0F", []string{"\u00A9\uFE0F"}},
+		{"in between words", "love\U0001F60Dyou", []string{"\U0001F60D"}},
+	}
+
+	for _, c := range cases {
+		if emoji := Emoji(c.text); !reflect.DeepEqual(emoji, c.emoji) {
+			t.Errorf("%s: Emoji(%q) = %q, expected %q", c.name, c.text, emoji, c.emoji)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+// Package stats counts up the messages in a chat log, ready to be reported on
+package stats
+
+import (
+	"fmt"
+	"github.com/rsalmond/kissyface/chat"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Histogram counts up messages by who sent them and when
+type Histogram struct {
+	Hours         map[int]map[string]int
+	Weekdays      map[time.Weekday]map[string]int
+	Users         map[string]int
+	TotalMessages int
+	// Heatmap counts each user's messages by day of the week and hour of the day together
+	Heatmap map[string]*[7][24]int
+	// AllTime counts messages in every hour from the first message to the last
+	AllTime Timeline
+	// First and Last are when the earliest and latest messages were sent
+	First time.Time
+	Last  time.Time
+
+	// Location is the time zone the timestamps in the chat log were written in
+	Location *time.Location
+	// UserLocations are the time zones individual users live in, hours and weekdays are counted on their clock
+	UserLocations map[string]*time.Location
+	// WeekStart is the day weekdays are listed from, Sunday unless it's set otherwise
+	WeekStart time.Weekday
+}
+
+// NewHistogram prepares an empty Histogram for timestamps written in location. Hours and weekdays are counted on the
+// clock of the user's own time zone in userLocations, or in location for anyone not listed there.
+func NewHistogram(location *time.Location, userLocations map[string]*time.Location) *Histogram {
+	h := new(Histogram)
+	// prepare all the histogram data
+	h.Hours = make(map[int]map[string]int, 24)
+	h.Weekdays = make(map[time.Weekday]map[string]int, 7)
+	h.Heatmap = make(map[string]*[7][24]int, 2)
+	h.Users = make(map[string]int, 2)
+	h.TotalMessages = 0
+	h.Location = location
+	h.UserLocations = userLocations
+	if h.UserLocations == nil {
+		h.UserLocations = make(map[string]*time.Location)
+	}
+	return h
+}
+
+// zone returns the time zone a user reads their clock in
+func (h *Histogram) zone(user string) *time.Location {
+	return userZone(user, h.Location, h.UserLocations)
+}
+
+// userZone returns the time zone user reads their clock in, their own from userLocations or location otherwise
+func userZone(user string, location *time.Location, userLocations map[string]*time.Location) *time.Location {
+	if zone, present := userLocations[user]; present {
+		return zone
+	}
+	return location
+}
+
+// Feed counts a single message
+func (h *Histogram) Feed(m chat.Message) {
+	// when the message was sent, and what time it was for the person who sent it
+	sent := m.Time.In(h.Location)
+	local := sent.In(h.zone(m.User))
+
+	// count every mesage
+	h.TotalMessages++
+	if h.First.IsZero() || sent.Before(h.First) {
+		h.First = sent
+	}
+	if h.Last.IsZero() || sent.After(h.Last) {
+		h.Last = sent
+	}
+	// sum up total messages by hour of the day they were sent
+	if _, hour_present := h.Hours[local.Hour()]; hour_present {
+		if _, user_present := h.Hours[local.Hour()][m.User]; user_present {
+			h.Hours[local.Hour()][m.User]++
+		} else {
+			h.Hours[local.Hour()][m.User] = 1
+		}
+	} else {
+		h.Hours[local.Hour()] = make(map[string]int, 2)
+		h.Hours[local.Hour()][m.User] = 1
+	}
+
+	// and by day of the week
+	if _, date_present := h.Weekdays[local.Weekday()]; date_present {
+		if _, user_present := h.Weekdays[local.Weekday()][m.User]; user_present {
+			h.Weekdays[local.Weekday()][m.User]++
+		} else {
+			h.Weekdays[local.Weekday()][m.User] = 1
+		}
+
+	} else {
+		h.Weekdays[local.Weekday()] = make(map[string]int, 2)
+		h.Weekdays[local.Weekday()][m.User] = 1
+	}
+
+	// and by both at once
+	if _, user_present := h.Heatmap[m.User]; !user_present {
+		h.Heatmap[m.User] = new([7][24]int)
+	}
+	h.Heatmap[m.User][local.Weekday()][local.Hour()]++
+
+	// and by messages per hour across all time (broken out by user)
+	h.AllTime.add(PerHour.start(sent), m.User)
+
+	// and by user who sent them
+	if _, present := h.Users[m.User]; present {
+		h.Users[m.User]++
+	} else {
+
+		h.Users[m.User] = 1
+	}
+
+	return
+}
+
+// Total returns how many messages were fed to the histogram
+func (h Histogram) Total() int {
+	return h.TotalMessages
+}
+
+// UserNames returns everyone who sent a message, in alphabetical order
+func (h Histogram) UserNames() []string {
+	usernames := make([]string, 0, len(h.Users))
+	for user := range h.Users {
+		usernames = append(usernames, user)
+	}
+	sort.Strings(usernames)
+	return usernames
+}
+
+// MessagesBy returns how many messages user sent
+func (h Histogram) MessagesBy(user string) int {
+	return h.Users[user]
+}
+
+// ByHour returns how many messages user sent during the given hour of the day (0-23) on their own clock
+func (h Histogram) ByHour(hour int, user string) int {
+	return h.Hours[hour][user]
+}
+
+// ByWeekday returns how many messages user sent on the given day of the week on their own clock
+func (h Histogram) ByWeekday(day time.Weekday, user string) int {
+	return h.Weekdays[day][user]
+}
+
+// ByWeekdayHour returns how many messages user sent during the given hour of the day (0-23) on the given day of the
+// week, on their own clock
+func (h Histogram) ByWeekdayHour(day time.Weekday, hour int, user string) int {
+	if heatmap, present := h.Heatmap[user]; present {
+		return heatmap[day][hour]
+	}
+	return 0
+}
+
+// WriteAllTimeCSV writes the number of messages each user sent in every hour from the first message to the last
+func (h Histogram) WriteAllTimeCSV(f io.Writer, opts CSVOptions) error {
+	return h.WriteSeriesCSV(f, opts, PerHour)
+}
+
+// eachHour calls fn for every hour from the one the first message was sent in to the one the last was, in order
+func (h Histogram) eachHour(fn func(hour time.Time, counts map[string]int)) {
+	for i := 0; i < h.AllTime.Hours(); i++ {
+		counts := make(map[string]int, len(h.AllTime.Counts))
+		for user := range h.AllTime.Counts {
+			counts[user] = h.AllTime.Count(i, user)
+		}
+		fn(h.AllTime.Hour(i).In(h.Location), counts)
+	}
+}
+
+// WriteHourlyCSV writes the number of messages each user sent in each hour of the day, from midnight on
+func (h Histogram) WriteHourlyCSV(f io.Writer, opts CSVOptions) error {
+	usernames := h.UserNames()
+
+	w := newCSVWriter(f, opts)
+	w.writeStrings(append([]string{"Hour"}, usernames...))
+
+	// every hour gets a row, even the ones nobody sent anything in
+	for hour := 0; hour < 24; hour++ {
+		w.write(fmt.Sprintf("%02d:00", hour), h.row(usernames, h.Hours[hour])...)
+	}
+
+	return w.close()
+}
+
+// WriteWeekdayCSV writes the number of messages each user sent on each day of the week, starting from WeekStart
+func (h Histogram) WriteWeekdayCSV(f io.Writer, opts CSVOptions) error {
+	usernames := h.UserNames()
+
+	w := newCSVWriter(f, opts)
+	w.writeStrings(append([]string{"Day"}, usernames...))
+
+	for _, day := range h.WeekdayOrder() {
+		w.write(day.String(), h.row(usernames, h.Weekdays[day])...)
+	}
+
+	return w.close()
+}
+
+// WriteHeatmapCSV writes the number of messages each user sent in each hour of each day of the week, a row for every
+// user and day with the hours across
+func (h Histogram) WriteHeatmapCSV(f io.Writer, opts CSVOptions) error {
+	w := newCSVWriter(f, opts)
+
+	header := []string{"User", "Day"}
+	for hour := 0; hour < 24; hour++ {
+		header = append(header, fmt.Sprintf("%02d:00", hour))
+	}
+	w.writeStrings(header)
+
+	for _, user := range h.UserNames() {
+		for _, day := range h.WeekdayOrder() {
+			row := []string{user, day.String()}
+			for hour := 0; hour < 24; hour++ {
+				row = append(row, strconv.Itoa(h.ByWeekdayHour(day, hour, user)))
+			}
+			w.writeStrings(row)
+		}
+	}
+
+	return w.close()
+}
+
+// row picks out the counts for a single bucket in the same order as usernames, missing users are counted as zero
+func (h Histogram) row(usernames []string, counts map[string]int) []int {
+	row := make([]int, 0, len(usernames))
+	for _, user := range usernames {
+		row = append(row, counts[user])
+	}
+	return row
+}
+
+// WeekdayOrder returns the days of the week starting from WeekStart
+func (h Histogram) WeekdayOrder() []time.Weekday {
+	return weekdayOrder(h.WeekStart)
+}
+
+func weekdayOrder(start time.Weekday) []time.Weekday {
+	days := make([]time.Weekday, 0, 7)
+	for i := 0; i < 7; i++ {
+		days = append(days, (start+time.Weekday(i))%7)
+	}
+	return days
+}
+
+// ChattiestDay returns the day of the week each user sends the most messages on, and how many they sent. Ties go to
+// the day closest to the start of the week.
+func (h Histogram) ChattiestDay() (map[string]time.Weekday, map[string]int) {
+
+	var chattiest_day = make(map[string]time.Weekday)
+	var chattiest_day_messages = make(map[string]int)
+
+	for _, day := range h.WeekdayOrder() {
+		for user, messages := range h.Weekdays[day] {
+			if messages > chattiest_day_messages[user] {
+				chattiest_day[user] = day
+				chattiest_day_messages[user] = messages
+			}
+		}
+	}
+
+	return chattiest_day, chattiest_day_messages
+}
+
+// ChattiestHour returns the hour of the day each user sends the most messages in, and how many they sent. Ties go to
+// the earliest hour.
+func (h Histogram) ChattiestHour() (map[string]int, map[string]int) {
+	var chattiest_hour = make(map[string]int)
+	var chattiest_hour_messages = make(map[string]int)
+
+	for hour := 0; hour < 24; hour++ {
+		for user, messages := range h.Hours[hour] {
+			if messages > chattiest_hour_messages[user] {
+				chattiest_hour[user] = hour
+				chattiest_hour_messages[user] = messages
+			}
+		}
+	}
+
+	return chattiest_hour, chattiest_hour_messages
+}
+
+// Report writes a short summary of the histogram in plain English
+func (h Histogram) Report(w io.Writer) {
+	fmt.Fprintf(w, "Total message sent: %d\n", h.TotalMessages)
+	usernames := h.UserNames()
+	for _, user := range usernames {
+		fmt.Fprintf(w, "User %s sent %d messages in total.\n", strings.Trim(user, " "), h.Users[user])
+	}
+
+	zoned := make([]string, 0, len(h.UserLocations))
+	for user := range h.UserLocations {
+		zoned = append(zoned, user)
+	}
+	sort.Strings(zoned)
+	for _, user := range zoned {
+		location := h.UserLocations[user]
+		if _, present := h.Users[user]; !present {
+			fmt.Fprintf(w, "Warning: there are no messages from %s, their time zone has been ignored.\n", user)
+			continue
+		}
+		fmt.Fprintf(w, "Hours and days for %s are counted in %s, for everyone else in %s.\n", user, location, h.Location)
+	}
+
+	day, daily_messages := h.ChattiestDay()
+	for _, user := range usernames {
+		fmt.Fprintf(w, "%s sends the most messages on %s, %d all told!\n", user, day[user], daily_messages[user])
+	}
+
+	hour, hourly_messages := h.ChattiestHour()
+	for _, user := range usernames {
+		fmt.Fprintf(w, "%s sends the most messages during the %dth hour of the day, %d all told!\n", user, hour[user], hourly_messages[user])
+	}
+}
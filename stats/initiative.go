@@ -0,0 +1,197 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Tally is how many conversations each user had a hand in, altogether and by when the conversations started on
+// that user's clock
+type Tally struct {
+	Users    map[string]int
+	Weekdays map[time.Weekday]map[string]int
+	Hours    [24]map[string]int
+}
+
+func (s Sessions) tally(who func(Session) string) Tally {
+	t := Tally{Users: make(map[string]int, 2), Weekdays: make(map[time.Weekday]map[string]int, 7)}
+	for _, session := range s.Conversations {
+		user := who(session)
+		t.Users[user]++
+
+		start := session.Start.In(userZone(user, s.Location, s.UserLocations))
+
+		day := start.Weekday()
+		if _, present := t.Weekdays[day]; !present {
+			t.Weekdays[day] = make(map[string]int, 2)
+		}
+		t.Weekdays[day][user]++
+
+		hour := start.Hour()
+		if t.Hours[hour] == nil {
+			t.Hours[hour] = make(map[string]int, 2)
+		}
+		t.Hours[hour][user]++
+	}
+	return t
+}
+
+// Starters counts who sent the first message of each conversation
+func (s Sessions) Starters() Tally {
+	return s.tally(func(session Session) string { return session.Starter })
+}
+
+// Enders counts who had the last word in each conversation
+func (s Sessions) Enders() Tally {
+	return s.tally(func(session Session) string { return session.Ender })
+}
+
+// UserNames returns everyone who started or ended a conversation, in alphabetical order
+func (s Sessions) UserNames() []string {
+	seen := make(map[string]bool)
+	for _, session := range s.Conversations {
+		seen[session.Starter] = true
+		seen[session.Ender] = true
+	}
+	usernames := make([]string, 0, len(seen))
+	for user := range seen {
+		usernames = append(usernames, user)
+	}
+	sort.Strings(usernames)
+	return usernames
+}
+
+// percent works out what share of total part is, rounded to the nearest whole percent
+func percent(part, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return (part*200 + total) / (total * 2)
+}
+
+// ReportInitiative writes who starts conversations and who has the last word in plain English
+func (s Sessions) ReportInitiative(w io.Writer) {
+	if len(s.Conversations) == 0 {
+		return
+	}
+
+	starters, enders := s.Starters(), s.Enders()
+	usernames := s.UserNames()
+	for _, user := range usernames {
+		fmt.Fprintf(w, "%s starts %d%% of conversations, and has the last word in %d%% of them.\n", user,
+			percent(starters.Users[user], len(s.Conversations)), percent(enders.Users[user], len(s.Conversations)))
+	}
+
+	for _, day := range s.WeekdayOrder() {
+		// everybody's Sunday is on their own clock, ties go to whoever comes first alphabetically
+		top, top_share := "", 0
+		for _, user := range usernames {
+			if share := percent(starters.Weekdays[day][user], s.startedOn(day, user)); share > top_share {
+				top, top_share = user, share
+			}
+		}
+		if top == "" {
+			continue
+		}
+		fmt.Fprintf(w, "%s starts %d%% of %s conversations.\n", top, top_share, day)
+	}
+}
+
+// startedOn counts the conversations that started on day, on user's clock
+func (s Sessions) startedOn(day time.Weekday, user string) int {
+	zone := userZone(user, s.Location, s.UserLocations)
+	count := 0
+	for _, session := range s.Conversations {
+		if session.Start.In(zone).Weekday() == day {
+			count++
+		}
+	}
+	return count
+}
+
+// WriteInitiativeWeekdayCSV writes how many conversations each user started and ended on each day of the week,
+// starting from WeekStart
+func (s Sessions) WriteInitiativeWeekdayCSV(f io.Writer, opts CSVOptions) error {
+	usernames := s.UserNames()
+	starters, enders := s.Starters(), s.Enders()
+
+	w := newCSVWriter(f, opts)
+	w.writeStrings(initiativeHeader("Day", usernames))
+	for _, day := range s.WeekdayOrder() {
+		w.write(day.String(), initiativeRow(usernames, starters.Weekdays[day], enders.Weekdays[day])...)
+	}
+	return w.close()
+}
+
+// WriteInitiativeHourlyCSV writes how many conversations each user started and ended in each hour of the day
+func (s Sessions) WriteInitiativeHourlyCSV(f io.Writer, opts CSVOptions) error {
+	usernames := s.UserNames()
+	starters, enders := s.Starters(), s.Enders()
+
+	w := newCSVWriter(f, opts)
+	w.writeStrings(initiativeHeader("Hour", usernames))
+	for hour := 0; hour < 24; hour++ {
+		w.write(fmt.Sprintf("%02d:00", hour), initiativeRow(usernames, starters.Hours[hour], enders.Hours[hour])...)
+	}
+	return w.close()
+}
+
+func initiativeHeader(label string, usernames []string) []string {
+	header := []string{label}
+	for _, user := range usernames {
+		header = append(header, user+" started", user+" ended")
+	}
+	return append(header, "Conversations")
+}
+
+// initiativeRow lays out each user's started and ended counts side by side, then the number of conversations, each
+// of which is counted on the clock of whoever started it
+func initiativeRow(usernames []string, started map[string]int, ended map[string]int) []int {
+	row := make([]int, 0, len(usernames)*2+1)
+	total := 0
+	for _, user := range usernames {
+		row = append(row, started[user], ended[user])
+		total += started[user]
+	}
+	return append(row, total)
+}
+
+// UserInitiative is how many conversations one user started and ended
+type UserInitiative struct {
+	Name      string           `json:"name"`
+	Started   int              `json:"started"`
+	Ended     int              `json:"ended"`
+	ByWeekday []InitiativeStep `json:"by_weekday"`
+	ByHour    []InitiativeStep `json:"by_hour"`
+}
+
+// InitiativeStep is how many of the conversations that started during Label one user started and ended
+type InitiativeStep struct {
+	Label   string `json:"label"`
+	Started int    `json:"started"`
+	Ended   int    `json:"ended"`
+}
+
+func (s Sessions) initiative() []UserInitiative {
+	starters, enders := s.Starters(), s.Enders()
+	users := make([]UserInitiative, 0)
+	for _, user := range s.UserNames() {
+		u := UserInitiative{
+			Name:      user,
+			Started:   starters.Users[user],
+			Ended:     enders.Users[user],
+			ByWeekday: make([]InitiativeStep, 0, 7),
+			ByHour:    make([]InitiativeStep, 0, 24),
+		}
+		for _, day := range s.WeekdayOrder() {
+			u.ByWeekday = append(u.ByWeekday, InitiativeStep{Label: day.String(), Started: starters.Weekdays[day][user], Ended: enders.Weekdays[day][user]})
+		}
+		for hour := 0; hour < 24; hour++ {
+			u.ByHour = append(u.ByHour, InitiativeStep{Label: fmt.Sprintf("%02d:00", hour), Started: starters.Hours[hour][user], Ended: enders.Hours[hour][user]})
+		}
+		users = append(users, u)
+	}
+	return users
+}
@@ -0,0 +1,44 @@
+package stats
+
+import (
+	"bytes"
+	"github.com/rsalmond/kissyface/chat"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInitiativeUserZones(t *testing.T) {
+	tokyo := time.FixedZone("UTC+9", 9*60*60)
+	s := NewSessions(time.UTC, map[string]*time.Location{"Anna": tokyo}, 30*time.Minute)
+
+	// late on a Saturday in UTC, which is already Sunday morning for Anna
+	saturday := time.Date(2018, 2, 3, 23, 0, 0, 0, time.UTC)
+	for _, m := range []chat.Message{
+		{Time: saturday, User: "Anna"},
+		{Time: saturday.Add(5 * time.Minute), User: "Ben"},
+		{Time: saturday.Add(2 * time.Hour), User: "Ben"},
+		{Time: saturday.Add(2*time.Hour + 5*time.Minute), User: "Anna"},
+	} {
+		s.Feed(m)
+	}
+
+	starters, enders := s.Starters(), s.Enders()
+	if starters.Weekdays[time.Sunday]["Anna"] != 1 || starters.Hours[8]["Anna"] != 1 {
+		t.Errorf("Anna started %v by weekday and %v by hour, expected a conversation at 8am on Sunday", starters.Weekdays, starters.Hours)
+	}
+	if starters.Weekdays[time.Sunday]["Ben"] != 1 || starters.Hours[1]["Ben"] != 1 {
+		t.Errorf("Ben started %v by weekday and %v by hour, expected a conversation at 1am on Sunday", starters.Weekdays, starters.Hours)
+	}
+	// Ben ended the first conversation, which started late on Saturday on his clock
+	if enders.Weekdays[time.Saturday]["Ben"] != 1 || enders.Hours[23]["Ben"] != 1 {
+		t.Errorf("Ben ended %v by weekday and %v by hour, expected a conversation from 11pm on Saturday", enders.Weekdays, enders.Hours)
+	}
+
+	// both conversations started on a Sunday for Anna, but only the one Ben started did for him
+	var report bytes.Buffer
+	s.ReportInitiative(&report)
+	if !strings.Contains(report.String(), "Ben starts 100% of Sunday conversations.") {
+		t.Errorf("unexpected report:\n%s", report.String())
+	}
+}
@@ -0,0 +1,439 @@
+package stats
+
+import (
+	"fmt"
+	"github.com/rsalmond/kissyface/chat"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MessageLengths measures how long everybody's messages are
+type MessageLengths struct {
+	// Location is the time zone messages are put into days and months in
+	Location *time.Location
+	// WallOfText is how many characters a message has to go over to count as a wall of text
+	WallOfText int
+	// Messages are how long each of each user's messages was, in the order they were fed
+	Messages map[string][]Length
+	// MediaOnly are how many photos, stickers and the like each user sent without any text, which aren't measured
+	MediaOnly map[string]int
+}
+
+// Length is how long one message was
+type Length struct {
+	Sent       time.Time
+	Characters int
+	Words      int
+	Runes      int
+}
+
+// lengthBounds are where the distribution of message lengths, in characters, is split
+var lengthBounds = []int{10, 25, 50, 100, 200, 500, 1000}
+
+// NewMessageLengths prepares to measure messages in chat logs with timestamps written in location, counting those
+// longer than wallOfText characters as walls of text
+func NewMessageLengths(location *time.Location, wallOfText int) *MessageLengths {
+	l := new(MessageLengths)
+	l.Location = location
+	l.WallOfText = wallOfText
+	l.Messages = make(map[string][]Length, 2)
+	l.MediaOnly = make(map[string]int, 2)
+	return l
+}
+
+// Feed measures a single message
+func (l *MessageLengths) Feed(m chat.Message) {
+	// an uncaptioned photo would otherwise count as a message with nothing in it
+	if m.MediaType != "" && strings.TrimSpace(m.Body) == "" {
+		l.MediaOnly[m.User]++
+		return
+	}
+	l.Messages[m.User] = append(l.Messages[m.User], Length{
+		Sent:       m.Time.In(l.Location),
+		Characters: Characters(m.Body),
+		Words:      len(strings.Fields(m.Body)),
+		Runes:      len([]rune(m.Body)),
+	})
+}
+
+// Characters counts the characters in text the way somebody reading it would, so an accent that's a separate code
+// point from its letter, or an emoji made up of several code points, only counts once
+func Characters(text string) int {
+	characters := 0
+	joined := false
+	regional := false
+	for _, r := range text {
+		switch {
+		case joined:
+			// whatever follows a zero width joiner is part of the character before it
+			joined = false
+		case r == zeroWidthJoiner:
+			joined = true
+		case unicode.IsMark(r) || unicode.Is(unicode.Cf, r) || isSkinTone(r):
+			// variation selectors, keycaps, tags and the like are all invisible on their own
+		case isRegionalIndicator(r):
+			// a pair of regional indicators is a single flag
+			if !regional {
+				characters++
+			}
+			regional = !regional
+			continue
+		default:
+			characters++
+		}
+		regional = false
+	}
+	return characters
+}
+
+// UserNames returns everyone who sent a message, with text or without, in alphabetical order
+func (l MessageLengths) UserNames() []string {
+	usernames := make([]string, 0, len(l.Messages))
+	for user := range l.Messages {
+		usernames = append(usernames, user)
+	}
+	for user := range l.MediaOnly {
+		if _, present := l.Messages[user]; !present {
+			usernames = append(usernames, user)
+		}
+	}
+	sort.Strings(usernames)
+	return usernames
+}
+
+// LengthStats are the characters, words and runes in a typical message
+type LengthStats struct {
+	Characters float64 `json:"characters"`
+	Words      float64 `json:"words"`
+	Runes      float64 `json:"runes"`
+}
+
+// Mean returns how long user's messages are on average
+func (l MessageLengths) Mean(user string) LengthStats {
+	return meanLength(l.Messages[user])
+}
+
+func meanLength(lengths []Length) LengthStats {
+	var mean LengthStats
+	if len(lengths) == 0 {
+		return mean
+	}
+	for _, length := range lengths {
+		mean.Characters += float64(length.Characters)
+		mean.Words += float64(length.Words)
+		mean.Runes += float64(length.Runes)
+	}
+	mean.Characters /= float64(len(lengths))
+	mean.Words /= float64(len(lengths))
+	mean.Runes /= float64(len(lengths))
+	return mean
+}
+
+// Median returns how long user's messages are at the median, of characters, words and runes each on their own
+func (l MessageLengths) Median(user string) LengthStats {
+	lengths := l.Messages[user]
+	characters := make([]int, 0, len(lengths))
+	words := make([]int, 0, len(lengths))
+	runes := make([]int, 0, len(lengths))
+	for _, length := range lengths {
+		characters = append(characters, length.Characters)
+		words = append(words, length.Words)
+		runes = append(runes, length.Runes)
+	}
+	return LengthStats{Characters: medianCount(characters), Words: medianCount(words), Runes: medianCount(runes)}
+}
+
+func medianCount(counts []int) float64 {
+	if len(counts) == 0 {
+		return 0
+	}
+	sort.Ints(counts)
+	if len(counts)%2 == 1 {
+		return float64(counts[len(counts)/2])
+	}
+	return float64(counts[len(counts)/2-1]+counts[len(counts)/2]) / 2
+}
+
+// Longest returns user's longest message in characters, the earliest of them if there's a tie
+func (l MessageLengths) Longest(user string) (Length, bool) {
+	lengths := l.Messages[user]
+	if len(lengths) == 0 {
+		return Length{}, false
+	}
+	longest := lengths[0]
+	for _, length := range lengths[1:] {
+		if length.Characters > longest.Characters || (length.Characters == longest.Characters && length.Sent.Before(longest.Sent)) {
+			longest = length
+		}
+	}
+	return longest, true
+}
+
+// WallsOfText returns how many of user's messages went over WallOfText characters
+func (l MessageLengths) WallsOfText(user string) int {
+	walls := 0
+	for _, length := range l.Messages[user] {
+		if length.Characters > l.WallOfText {
+			walls++
+		}
+	}
+	return walls
+}
+
+// DistributionLabels names the parts Distribution splits message lengths into, eg. "25 to 50 characters"
+func (l MessageLengths) DistributionLabels() []string {
+	labels := make([]string, 0, len(lengthBounds)+1)
+	for i, bound := range lengthBounds {
+		if i == 0 {
+			labels = append(labels, fmt.Sprintf("under %d characters", bound))
+			continue
+		}
+		labels = append(labels, fmt.Sprintf("%d to %d characters", lengthBounds[i-1], bound))
+	}
+	return append(labels, fmt.Sprintf("%d characters or more", lengthBounds[len(lengthBounds)-1]))
+}
+
+// Distribution returns how many of user's messages fall into each of the parts named by DistributionLabels
+func (l MessageLengths) Distribution(user string) []int {
+	counts := make([]int, len(lengthBounds)+1)
+	for _, length := range l.Messages[user] {
+		i := sort.Search(len(lengthBounds), func(i int) bool { return length.Characters < lengthBounds[i] })
+		counts[i]++
+	}
+	return counts
+}
+
+// LengthStep is how many messages each user sent during one stretch of time, and how long they were on average
+type LengthStep struct {
+	Label    string                 `json:"label"`
+	Messages map[string]int         `json:"messages"`
+	Mean     map[string]LengthStats `json:"mean"`
+}
+
+// Series works out how long everybody's messages were on average in every step of r from the first message to the last
+func (l MessageLengths) Series(r Resolution) []LengthStep {
+	series := make([]LengthStep, 0)
+
+	var first, last time.Time
+	steps := make(map[int64]map[string][]Length)
+	for user, lengths := range l.Messages {
+		for _, length := range lengths {
+			if first.IsZero() || length.Sent.Before(first) {
+				first = length.Sent
+			}
+			if last.IsZero() || length.Sent.After(last) {
+				last = length.Sent
+			}
+			step := r.start(length.Sent).Unix()
+			if _, present := steps[step]; !present {
+				steps[step] = make(map[string][]Length, 2)
+			}
+			steps[step][user] = append(steps[step][user], length)
+		}
+	}
+	if first.IsZero() {
+		return series
+	}
+
+	usernames := l.UserNames()
+	for step := r.start(first); !step.After(last); step = r.next(step) {
+		s := LengthStep{
+			Label:    r.label(step),
+			Messages: make(map[string]int, len(usernames)),
+			Mean:     make(map[string]LengthStats, len(usernames)),
+		}
+		for _, user := range usernames {
+			lengths := steps[step.Unix()][user]
+			s.Messages[user] = len(lengths)
+			s.Mean[user] = meanLength(lengths)
+		}
+		series = append(series, s)
+	}
+	return series
+}
+
+// Report writes how long everybody's messages are in plain English
+func (l MessageLengths) Report(w io.Writer) {
+	usernames := l.UserNames()
+	for _, user := range usernames {
+		if media := l.MediaOnly[user]; media > 0 {
+			fmt.Fprintf(w, "%s's photos, stickers and the like without any text (%d of them) aren't measured.\n", user, media)
+		}
+		if len(l.Messages[user]) == 0 {
+			continue
+		}
+
+		mean, median := l.Mean(user), l.Median(user)
+		fmt.Fprintf(w, "%s's messages are %.1f characters (%.1f words) long on average, and %.0f characters at the median.\n",
+			user, mean.Characters, mean.Words, median.Characters)
+
+		longest, _ := l.Longest(user)
+		fmt.Fprintf(w, "%s's longest message was %d characters, sent on %s.\n",
+			user, longest.Characters, longest.Sent.Format("Monday 2 January 2006 at 15:04"))
+
+		switch walls := l.WallsOfText(user); walls {
+		case 0:
+		case 1:
+			fmt.Fprintf(w, "%s wrote a wall of text (over %d characters).\n", user, l.WallOfText)
+		default:
+			fmt.Fprintf(w, "%s wrote %d walls of text (over %d characters).\n", user, walls, l.WallOfText)
+		}
+	}
+
+	if len(l.Messages) > 1 {
+		wordiest := usernames[0]
+		for _, user := range usernames[1:] {
+			if l.Mean(user).Characters > l.Mean(wordiest).Characters {
+				wordiest = user
+			}
+		}
+		fmt.Fprintf(w, "%s is the wordiest!\n", wordiest)
+	}
+}
+
+func formatMean(mean float64) string {
+	return strconv.FormatFloat(mean, 'f', 2, 64)
+}
+
+// WriteCSV writes how long each user's messages are on average, their longest message and their walls of text, along
+// with how many messages they sent that had no text to measure
+func (l MessageLengths) WriteCSV(f io.Writer, opts CSVOptions) error {
+	w := newCSVWriter(f, opts)
+	w.writeStrings([]string{
+		"User", "Messages",
+		"Mean characters", "Median characters", "Mean words", "Median words", "Mean runes", "Median runes",
+		"Longest characters", "Longest sent", "Walls of text", "Media only",
+	})
+	for _, user := range l.UserNames() {
+		mean, median := l.Mean(user), l.Median(user)
+		longest, present := l.Longest(user)
+		sent := ""
+		if present {
+			sent = longest.Sent.Format(time.RFC3339)
+		}
+		w.writeStrings([]string{
+			user,
+			strconv.Itoa(len(l.Messages[user])),
+			formatMean(mean.Characters),
+			formatMean(median.Characters),
+			formatMean(mean.Words),
+			formatMean(median.Words),
+			formatMean(mean.Runes),
+			formatMean(median.Runes),
+			strconv.Itoa(longest.Characters),
+			sent,
+			strconv.Itoa(l.WallsOfText(user)),
+			strconv.Itoa(l.MediaOnly[user]),
+		})
+	}
+	return w.close()
+}
+
+// WriteDistributionCSV writes how many of each user's messages were how long
+func (l MessageLengths) WriteDistributionCSV(f io.Writer, opts CSVOptions) error {
+	usernames := l.UserNames()
+
+	w := newCSVWriter(f, opts)
+	w.writeStrings(append([]string{"Length"}, usernames...))
+
+	distributions := make([][]int, 0, len(usernames))
+	for _, user := range usernames {
+		distributions = append(distributions, l.Distribution(user))
+	}
+	for i, label := range l.DistributionLabels() {
+		row := make([]int, 0, len(usernames))
+		for _, distribution := range distributions {
+			row = append(row, distribution[i])
+		}
+		w.write(label, row...)
+	}
+
+	return w.close()
+}
+
+// WriteSeriesCSV writes how many messages each user sent in every step of r, and how many characters and words they
+// were on average
+func (l MessageLengths) WriteSeriesCSV(f io.Writer, opts CSVOptions, r Resolution) error {
+	usernames := l.UserNames()
+
+	w := newCSVWriter(f, opts)
+	header := []string{r.heading()}
+	for _, user := range usernames {
+		header = append(header, user+" messages", user+" mean characters", user+" mean words")
+	}
+	w.writeStrings(header)
+
+	for _, step := range l.Series(r) {
+		row := []string{step.Label}
+		for _, user := range usernames {
+			row = append(row, strconv.Itoa(step.Messages[user]), formatMean(step.Mean[user].Characters), formatMean(step.Mean[user].Words))
+		}
+		w.writeStrings(row)
+	}
+	return w.close()
+}
+
+// LengthSummary is everything MessageLengths found out, laid out to be marshalled to JSON
+type LengthSummary struct {
+	WallOfText int           `json:"wall_of_text"`
+	Users      []UserLengths `json:"users"`
+	Monthly    []LengthStep  `json:"monthly"`
+}
+
+// UserLengths is how long one user's messages are
+type UserLengths struct {
+	Name string `json:"name"`
+	// Messages are the messages with text in them, MediaOnly the ones without, which aren't measured
+	Messages     int             `json:"messages"`
+	MediaOnly    int             `json:"media_only"`
+	Mean         LengthStats     `json:"mean"`
+	Median       LengthStats     `json:"median"`
+	Longest      *LongestMessage `json:"longest,omitempty"`
+	WallsOfText  int             `json:"walls_of_text"`
+	Distribution []LengthBucket  `json:"distribution"`
+}
+
+// LongestMessage is when somebody's longest message was sent, and how long it was
+type LongestMessage struct {
+	Sent       time.Time `json:"sent"`
+	Characters int       `json:"characters"`
+	Words      int       `json:"words"`
+	Runes      int       `json:"runes"`
+}
+
+// LengthBucket is how many messages were about as long as Label says
+type LengthBucket struct {
+	Label    string `json:"label"`
+	Messages int    `json:"messages"`
+}
+
+// Summary gathers up everyone's message lengths, with every part of the distribution and month present
+func (l MessageLengths) Summary() LengthSummary {
+	s := LengthSummary{WallOfText: l.WallOfText, Users: make([]UserLengths, 0, len(l.Messages)), Monthly: l.Series(PerMonth)}
+
+	labels := l.DistributionLabels()
+	for _, user := range l.UserNames() {
+		u := UserLengths{
+			Name:         user,
+			Messages:     len(l.Messages[user]),
+			MediaOnly:    l.MediaOnly[user],
+			Mean:         l.Mean(user),
+			Median:       l.Median(user),
+			WallsOfText:  l.WallsOfText(user),
+			Distribution: make([]LengthBucket, 0, len(labels)),
+		}
+		if longest, present := l.Longest(user); present {
+			u.Longest = &LongestMessage{Sent: longest.Sent, Characters: longest.Characters, Words: longest.Words, Runes: longest.Runes}
+		}
+		for i, count := range l.Distribution(user) {
+			u.Distribution = append(u.Distribution, LengthBucket{Label: labels[i], Messages: count})
+		}
+		s.Users = append(s.Users, u)
+	}
+
+	return s
+}
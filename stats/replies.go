@@ -0,0 +1,317 @@
+package stats
+
+import (
+	"fmt"
+	"github.com/rsalmond/kissyface/chat"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReplyTimes measures how long users take to answer each other. Whenever the sender changes, the time since the
+// message before counts as a reply by the new sender.
+type ReplyTimes struct {
+	// Cutoff is the longest gap still counted as a reply, anything longer (like a night's sleep) starts afresh
+	Cutoff time.Duration
+	// Location and UserLocations are the time zones replies are put into hours of the day in, as for a Histogram
+	Location      *time.Location
+	UserLocations map[string]*time.Location
+	// Delays are how long each user took over each of their replies, in the order they were sent
+	Delays map[string][]time.Duration
+	// Hours are the hours of the day, on the replier's clock, each of their Delays ended in
+	Hours map[string][]int
+
+	previous chat.Message
+}
+
+// replyBounds are where the distribution of reply times is split, those past the cutoff are left off
+var replyBounds = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	2 * time.Hour,
+	4 * time.Hour,
+	8 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+}
+
+// NewReplyTimes prepares to measure replies no more than cutoff apart, in chat logs with timestamps written in
+// location. Hours of the day are on each user's clock, as for NewHistogram.
+func NewReplyTimes(location *time.Location, userLocations map[string]*time.Location, cutoff time.Duration) *ReplyTimes {
+	r := new(ReplyTimes)
+	r.Cutoff = cutoff
+	r.Location = location
+	r.UserLocations = userLocations
+	if r.UserLocations == nil {
+		r.UserLocations = make(map[string]*time.Location)
+	}
+	r.Delays = make(map[string][]time.Duration, 2)
+	r.Hours = make(map[string][]int, 2)
+	return r
+}
+
+// Feed measures a single message against the one before it, messages have to be fed in the order they were sent
+func (r *ReplyTimes) Feed(m chat.Message) {
+	previous := r.previous
+	r.previous = m
+
+	// the very first message, somebody talking to themselves, or a log that jumps back in time aren't replies
+	if previous.User == "" || previous.User == m.User || m.Time.Before(previous.Time) {
+		return
+	}
+
+	delay := m.Time.Sub(previous.Time)
+	if delay > r.Cutoff {
+		return
+	}
+
+	r.Delays[m.User] = append(r.Delays[m.User], delay)
+	r.Hours[m.User] = append(r.Hours[m.User], m.Time.In(userZone(m.User, r.Location, r.UserLocations)).Hour())
+}
+
+// UserNames returns everyone who replied to anybody, in alphabetical order
+func (r ReplyTimes) UserNames() []string {
+	usernames := make([]string, 0, len(r.Delays))
+	for user := range r.Delays {
+		usernames = append(usernames, user)
+	}
+	sort.Strings(usernames)
+	return usernames
+}
+
+// Median returns the middle of user's reply times, zero if they never replied
+func (r ReplyTimes) Median(user string) time.Duration {
+	return median(r.Delays[user])
+}
+
+// Percentile returns the reply time p (between 0 and 1) of user's replies were at least as quick as
+func (r ReplyTimes) Percentile(user string, p float64) time.Duration {
+	return percentile(r.Delays[user], p)
+}
+
+func sorted(delays []time.Duration) []time.Duration {
+	s := append([]time.Duration(nil), delays...)
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	return s
+}
+
+func median(delays []time.Duration) time.Duration {
+	if len(delays) == 0 {
+		return 0
+	}
+	s := sorted(delays)
+	if len(s)%2 == 1 {
+		return s[len(s)/2]
+	}
+	return (s[len(s)/2-1] + s[len(s)/2]) / 2
+}
+
+// percentile picks the nearest rank, so it's always one of the reply times actually seen
+func percentile(delays []time.Duration, p float64) time.Duration {
+	if len(delays) == 0 {
+		return 0
+	}
+	s := sorted(delays)
+	rank := int(math.Ceil(p*float64(len(s)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return s[rank]
+}
+
+// bounds returns the upper end of each part of the distribution, the last of them is the cutoff
+func (r ReplyTimes) bounds() []time.Duration {
+	bounds := make([]time.Duration, 0, len(replyBounds)+1)
+	for _, bound := range replyBounds {
+		if bound < r.Cutoff {
+			bounds = append(bounds, bound)
+		}
+	}
+	return append(bounds, r.Cutoff)
+}
+
+// DistributionLabels names the parts Distribution splits reply times into, eg. "5m to 15m"
+func (r ReplyTimes) DistributionLabels() []string {
+	labels := make([]string, 0)
+	for i, bound := range r.bounds() {
+		if i == 0 {
+			labels = append(labels, fmt.Sprintf("under %s", shortDuration(bound)))
+			continue
+		}
+		labels = append(labels, fmt.Sprintf("%s to %s", shortDuration(r.bounds()[i-1]), shortDuration(bound)))
+	}
+	return labels
+}
+
+// Distribution returns how many of user's replies fall into each of the parts named by DistributionLabels
+func (r ReplyTimes) Distribution(user string) []int {
+	bounds := r.bounds()
+	counts := make([]int, len(bounds))
+	for _, delay := range r.Delays[user] {
+		// the last part goes right up to and includes the cutoff
+		i := sort.Search(len(bounds)-1, func(i int) bool { return delay < bounds[i] })
+		counts[i]++
+	}
+	return counts
+}
+
+// byHour splits user's reply times up by the hour of the day they replied in
+func (r ReplyTimes) byHour(user string) [24][]time.Duration {
+	var hours [24][]time.Duration
+	for i, delay := range r.Delays[user] {
+		hour := r.Hours[user][i]
+		hours[hour] = append(hours[hour], delay)
+	}
+	return hours
+}
+
+// shortDuration writes d without any zero minutes or seconds hanging off the end, eg. 4h rather than 4h0m0s
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// Report writes how quickly everybody replies in plain English
+func (r ReplyTimes) Report(w io.Writer) {
+	usernames := r.UserNames()
+	if len(usernames) == 0 {
+		fmt.Fprintf(w, "Nobody replied to anybody within %s.\n", shortDuration(r.Cutoff))
+		return
+	}
+
+	for _, user := range usernames {
+		fmt.Fprintf(w, "%s replies in %s at the median, and within %s 90%% of the time (%d replies).\n", user,
+			shortDuration(r.Median(user).Round(time.Second)), shortDuration(r.Percentile(user, 0.9).Round(time.Second)), len(r.Delays[user]))
+	}
+
+	if len(usernames) > 1 {
+		quickest := usernames[0]
+		for _, user := range usernames[1:] {
+			if r.Median(user) < r.Median(quickest) {
+				quickest = user
+			}
+		}
+		fmt.Fprintf(w, "%s is the quickest to reply!\n", quickest)
+	}
+}
+
+// WriteDistributionCSV writes how many of each user's replies took how long
+func (r ReplyTimes) WriteDistributionCSV(f io.Writer, opts CSVOptions) error {
+	usernames := r.UserNames()
+
+	w := newCSVWriter(f, opts)
+	w.writeStrings(append([]string{"Reply time"}, usernames...))
+
+	distributions := make([][]int, 0, len(usernames))
+	for _, user := range usernames {
+		distributions = append(distributions, r.Distribution(user))
+	}
+	for i, label := range r.DistributionLabels() {
+		row := make([]int, 0, len(usernames))
+		for _, distribution := range distributions {
+			row = append(row, distribution[i])
+		}
+		w.write(label, row...)
+	}
+
+	return w.close()
+}
+
+// WriteByHourCSV writes how many replies each user sent in each hour of the day and their median reply time in
+// seconds, which is left blank for hours they never replied in
+func (r ReplyTimes) WriteByHourCSV(f io.Writer, opts CSVOptions) error {
+	usernames := r.UserNames()
+
+	w := newCSVWriter(f, opts)
+	header := []string{"Hour"}
+	hours := make([][24][]time.Duration, 0, len(usernames))
+	for _, user := range usernames {
+		header = append(header, user+" replies", user+" median seconds")
+		hours = append(hours, r.byHour(user))
+	}
+	w.writeStrings(header)
+
+	for hour := 0; hour < 24; hour++ {
+		row := []string{fmt.Sprintf("%02d:00", hour)}
+		for i := range usernames {
+			delays := hours[i][hour]
+			seconds := ""
+			if len(delays) > 0 {
+				seconds = strconv.Itoa(int(median(delays).Seconds()))
+			}
+			row = append(row, strconv.Itoa(len(delays)), seconds)
+		}
+		w.writeStrings(row)
+	}
+
+	return w.close()
+}
+
+// ReplySummary is everything ReplyTimes found out, laid out to be marshalled to JSON
+type ReplySummary struct {
+	CutoffSeconds float64       `json:"cutoff_seconds"`
+	Users         []UserReplies `json:"users"`
+}
+
+// UserReplies is how quickly one user replies
+type UserReplies struct {
+	Name          string        `json:"name"`
+	Replies       int           `json:"replies"`
+	MedianSeconds float64       `json:"median_seconds"`
+	P90Seconds    float64       `json:"p90_seconds"`
+	Distribution  []ReplyBucket `json:"distribution"`
+	ByHour        []ReplyHour   `json:"by_hour"`
+}
+
+// ReplyBucket is how many replies took about as long as Label says
+type ReplyBucket struct {
+	Label   string `json:"label"`
+	Replies int    `json:"replies"`
+}
+
+// ReplyHour is how many replies were sent during an hour of the day, and how long they took
+type ReplyHour struct {
+	Hour          int     `json:"hour"`
+	Replies       int     `json:"replies"`
+	MedianSeconds float64 `json:"median_seconds"`
+}
+
+// Summary gathers up the reply times, with every part of the distribution and hour of the day present
+func (r ReplyTimes) Summary() ReplySummary {
+	s := ReplySummary{CutoffSeconds: r.Cutoff.Seconds(), Users: make([]UserReplies, 0)}
+
+	labels := r.DistributionLabels()
+	for _, user := range r.UserNames() {
+		u := UserReplies{
+			Name:          user,
+			Replies:       len(r.Delays[user]),
+			MedianSeconds: r.Median(user).Seconds(),
+			P90Seconds:    r.Percentile(user, 0.9).Seconds(),
+			Distribution:  make([]ReplyBucket, 0, len(labels)),
+			ByHour:        make([]ReplyHour, 0, 24),
+		}
+
+		for i, count := range r.Distribution(user) {
+			u.Distribution = append(u.Distribution, ReplyBucket{Label: labels[i], Replies: count})
+		}
+		for hour, delays := range r.byHour(user) {
+			u.ByHour = append(u.ByHour, ReplyHour{Hour: hour, Replies: len(delays), MedianSeconds: median(delays).Seconds()})
+		}
+
+		s.Users = append(s.Users, u)
+	}
+
+	return s
+}
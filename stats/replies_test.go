@@ -0,0 +1,125 @@
+package stats
+
+import (
+	"github.com/rsalmond/kissyface/chat"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMedian(t *testing.T) {
+	cases := []struct {
+		name   string
+		delays []time.Duration
+		median time.Duration
+	}{
+		{"none", nil, 0},
+		{"one", []time.Duration{time.Minute}, time.Minute},
+		{"odd", []time.Duration{5 * time.Minute, time.Minute, 3 * time.Minute}, 3 * time.Minute},
+		{"even", []time.Duration{4 * time.Minute, time.Minute, 2 * time.Minute, 10 * time.Minute}, 3 * time.Minute},
+	}
+
+	for _, c := range cases {
+		if m := median(c.delays); m != c.median {
+			t.Errorf("%s: median %s, expected %s", c.name, m, c.median)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	// one to ten minutes, in no particular order
+	delays := make([]time.Duration, 0, 10)
+	for _, minutes := range []int{7, 2, 9, 1, 10, 4, 3, 8, 6, 5} {
+		delays = append(delays, time.Duration(minutes)*time.Minute)
+	}
+
+	cases := []struct {
+		p     float64
+		delay time.Duration
+	}{
+		{0, time.Minute},
+		{0.1, time.Minute},
+		{0.15, 2 * time.Minute},
+		{0.5, 5 * time.Minute},
+		{0.9, 9 * time.Minute},
+		{0.91, 10 * time.Minute},
+		{1, 10 * time.Minute},
+	}
+
+	for _, c := range cases {
+		if d := percentile(delays, c.p); d != c.delay {
+			t.Errorf("percentile %v is %s, expected %s", c.p, d, c.delay)
+		}
+	}
+	if d := percentile(nil, 0.9); d != 0 {
+		t.Errorf("percentile of nothing is %s, expected 0", d)
+	}
+}
+
+// replies feeds Anna and Ben taking turns, each message delays after the one before
+func replies(r *ReplyTimes, delays ...time.Duration) {
+	sent := time.Date(2018, 2, 1, 10, 0, 0, 0, time.UTC)
+	users := []string{"Anna", "Ben"}
+	r.Feed(chat.Message{Time: sent, User: users[0]})
+	for i, delay := range delays {
+		sent = sent.Add(delay)
+		r.Feed(chat.Message{Time: sent, User: users[(i+1)%2]})
+	}
+}
+
+func TestReplyTimesFeed(t *testing.T) {
+	r := NewReplyTimes(time.UTC, nil, time.Hour)
+	start := time.Date(2018, 2, 1, 10, 0, 0, 0, time.UTC)
+	for _, m := range []chat.Message{
+		{Time: start, User: "Anna"},
+		{Time: start.Add(time.Minute), User: "Anna"},
+		{Time: start.Add(3 * time.Minute), User: "Ben"},
+		// a log which jumps back in time
+		{Time: start.Add(2 * time.Minute), User: "Anna"},
+		// overnight
+		{Time: start.Add(14 * time.Hour), User: "Ben"},
+		{Time: start.Add(14*time.Hour + 30*time.Second), User: "Anna"},
+	} {
+		r.Feed(m)
+	}
+
+	expected := map[string][]time.Duration{"Ben": {2 * time.Minute}, "Anna": {30 * time.Second}}
+	if !reflect.DeepEqual(r.Delays, expected) {
+		t.Errorf("delays %v, expected %v", r.Delays, expected)
+	}
+	if hours := map[string][]int{"Ben": {10}, "Anna": {0}}; !reflect.DeepEqual(r.Hours, hours) {
+		t.Errorf("hours %v, expected %v", r.Hours, hours)
+	}
+}
+
+func TestReplyTimesDistribution(t *testing.T) {
+	r := NewReplyTimes(time.UTC, nil, 2*time.Hour)
+	// Ben's replies sit on the bounds, Anna's just under them. The last one is just past the cutoff.
+	replies(r, time.Minute, 59*time.Second, time.Hour, 2*time.Hour-time.Second, 2*time.Hour, 2*time.Hour+time.Second)
+
+	labels := []string{"under 1m", "1m to 5m", "5m to 15m", "15m to 30m", "30m to 1h", "1h to 2h"}
+	if !reflect.DeepEqual(r.DistributionLabels(), labels) {
+		t.Errorf("labels %q, expected %q", r.DistributionLabels(), labels)
+	}
+	if d := r.Distribution("Ben"); !reflect.DeepEqual(d, []int{0, 1, 0, 0, 0, 2}) {
+		t.Errorf("Ben's distribution %v, expected [0 1 0 0 0 2]", d)
+	}
+	if d := r.Distribution("Anna"); !reflect.DeepEqual(d, []int{1, 0, 0, 0, 0, 1}) {
+		t.Errorf("Anna's distribution %v, expected [1 0 0 0 0 1]", d)
+	}
+}
+
+func TestReplyTimesDistributionShortCutoff(t *testing.T) {
+	r := NewReplyTimes(time.UTC, nil, 30*time.Second)
+	replies(r, 10*time.Second, 30*time.Second, 31*time.Second)
+
+	if labels := r.DistributionLabels(); !reflect.DeepEqual(labels, []string{"under 30s"}) {
+		t.Errorf("labels %q, expected [\"under 30s\"]", labels)
+	}
+	if d := r.Distribution("Ben"); !reflect.DeepEqual(d, []int{1}) {
+		t.Errorf("Ben's distribution %v, expected [1]", d)
+	}
+	if d := r.Distribution("Anna"); !reflect.DeepEqual(d, []int{1}) {
+		t.Errorf("Anna's distribution %v, expected [1]", d)
+	}
+}
@@ -0,0 +1,145 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Resolution is how long a stretch of time each step of a time series covers
+type Resolution int
+
+const (
+	PerHour Resolution = iota
+	PerDay
+	// PerWeek steps through ISO 8601 weeks, which start on a Monday whatever WeekStart says
+	PerWeek
+	PerMonth
+	PerYear
+)
+
+// Resolutions lists every resolution from the finest to the coarsest
+func Resolutions() []Resolution {
+	return []Resolution{PerHour, PerDay, PerWeek, PerMonth, PerYear}
+}
+
+func (r Resolution) String() string {
+	switch r {
+	case PerHour:
+		return "hour"
+	case PerDay:
+		return "day"
+	case PerWeek:
+		return "week"
+	case PerMonth:
+		return "month"
+	case PerYear:
+		return "year"
+	}
+	return fmt.Sprintf("Resolution(%d)", int(r))
+}
+
+// heading is the name of r as a CSV column header, eg. "Week"
+func (r Resolution) heading() string {
+	name := r.String()
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// start returns the beginning of the step t falls in, on t's clock
+func (r Resolution) start(t time.Time) time.Time {
+	switch r {
+	case PerHour:
+		return t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	case PerWeek:
+		day := PerDay.start(t)
+		return day.AddDate(0, 0, -int((day.Weekday()+6)%7))
+	case PerMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case PerYear:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// next returns the beginning of the step after the one starting at t
+func (r Resolution) next(t time.Time) time.Time {
+	switch r {
+	case PerHour:
+		return t.Add(time.Hour)
+	case PerWeek:
+		return t.AddDate(0, 0, 7)
+	case PerMonth:
+		return t.AddDate(0, 1, 0)
+	case PerYear:
+		return t.AddDate(1, 0, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// label names the step starting at t, eg. 2018-W05 for the fifth week of 2018
+func (r Resolution) label(t time.Time) string {
+	switch r {
+	case PerHour:
+		return t.Format(time.RFC3339)
+	case PerWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	case PerMonth:
+		return t.Format("2006-01")
+	case PerYear:
+		return t.Format("2006")
+	}
+	return t.Format("2006-01-02")
+}
+
+// Series adds the all time counts up into steps of r, with every step from the first message's to the last's there
+// even if nobody sent anything during it
+func (h Histogram) Series(r Resolution) []Bucket {
+	usernames := h.UserNames()
+	buckets := make([]Bucket, 0)
+
+	if r == PerHour {
+		h.eachHour(func(hour time.Time, counts map[string]int) {
+			buckets = append(buckets, h.bucket(r.label(hour), usernames, counts))
+		})
+		return buckets
+	}
+
+	if h.TotalMessages == 0 {
+		return buckets
+	}
+
+	// keyed on the unix time the step starts at, time.Times that are the same instant don't always compare equal
+	steps := make(map[int64]map[string]int)
+	h.eachHour(func(hour time.Time, counts map[string]int) {
+		step := r.start(hour).Unix()
+		if _, present := steps[step]; !present {
+			steps[step] = make(map[string]int, len(usernames))
+		}
+		for user, count := range counts {
+			steps[step][user] += count
+		}
+	})
+
+	for step := r.start(h.First); !step.After(h.Last); step = r.next(step) {
+		buckets = append(buckets, h.bucket(r.label(step), usernames, steps[step.Unix()]))
+	}
+	return buckets
+}
+
+// WriteSeriesCSV writes the number of messages each user sent, and everyone together, in every step of r from the
+// first message to the last
+func (h Histogram) WriteSeriesCSV(f io.Writer, opts CSVOptions, r Resolution) error {
+	usernames := h.UserNames()
+
+	w := newCSVWriter(f, opts)
+	header := append([]string{r.heading()}, usernames...)
+	w.writeStrings(append(header, "Total"))
+
+	for _, b := range h.Series(r) {
+		w.write(b.Label, append(h.row(usernames, b.Counts), b.Total)...)
+	}
+
+	return w.close()
+}
@@ -0,0 +1,308 @@
+package stats
+
+import (
+	"fmt"
+	"github.com/rsalmond/kissyface/chat"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Sessions splits a chat up into conversations, wherever nobody says anything for longer than Gap
+type Sessions struct {
+	// Gap is the longest silence a conversation can carry on through
+	Gap time.Duration
+	// Location is the time zone conversations are put into days and hours of the day in. Who starts and ends them is
+	// counted on their own clock, in UserLocations, as for a Histogram.
+	Location      *time.Location
+	UserLocations map[string]*time.Location
+	// WeekStart is the day weekdays are listed from, Sunday unless it's set otherwise
+	WeekStart time.Weekday
+	// Conversations are in the order they started
+	Conversations []Session
+}
+
+// Session is one conversation
+type Session struct {
+	// Start and End are when the first and last messages in it were sent
+	Start    time.Time
+	End      time.Time
+	Messages int
+	// Starter sent the first message, and Ender had the last word
+	Starter string
+	Ender   string
+}
+
+// Length returns how long the conversation went on for
+func (s Session) Length() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// NewSessions prepares to split a chat into conversations wherever there's a gap longer than gap between messages,
+// in chat logs with timestamps written in location. Who starts and ends conversations is counted on each user's
+// clock, as for NewHistogram.
+func NewSessions(location *time.Location, userLocations map[string]*time.Location, gap time.Duration) *Sessions {
+	s := new(Sessions)
+	s.Gap = gap
+	s.Location = location
+	s.UserLocations = userLocations
+	if s.UserLocations == nil {
+		s.UserLocations = make(map[string]*time.Location)
+	}
+	s.Conversations = make([]Session, 0)
+	return s
+}
+
+// Feed adds a single message to the conversation it's part of, messages have to be fed in the order they were sent
+func (s *Sessions) Feed(m chat.Message) {
+	sent := m.Time.In(s.Location)
+
+	if len(s.Conversations) == 0 || sent.Sub(s.Conversations[len(s.Conversations)-1].End) > s.Gap {
+		s.Conversations = append(s.Conversations, Session{Start: sent, End: sent, Starter: m.User})
+	}
+
+	current := &s.Conversations[len(s.Conversations)-1]
+	current.Messages++
+	// a log that jumps back in time leaves the conversation where it was
+	if !sent.Before(current.End) {
+		current.End = sent
+		current.Ender = m.User
+	}
+}
+
+// Total returns how many conversations there were
+func (s Sessions) Total() int {
+	return len(s.Conversations)
+}
+
+// Longest returns the conversation that went on for longest, the earliest of them if there's a tie
+func (s Sessions) Longest() (Session, bool) {
+	if len(s.Conversations) == 0 {
+		return Session{}, false
+	}
+	longest := s.Conversations[0]
+	for _, session := range s.Conversations[1:] {
+		if session.Length() > longest.Length() {
+			longest = session
+		}
+	}
+	return longest, true
+}
+
+// Average returns how long conversations go on for, and how many messages they take, on average
+func (s Sessions) Average() (time.Duration, float64) {
+	if len(s.Conversations) == 0 {
+		return 0, 0
+	}
+	var length time.Duration
+	messages := 0
+	for _, session := range s.Conversations {
+		length += session.Length()
+		messages += session.Messages
+	}
+	return length / time.Duration(len(s.Conversations)), float64(messages) / float64(len(s.Conversations))
+}
+
+// ByWeekday returns how many conversations started on each day of the week
+func (s Sessions) ByWeekday() map[time.Weekday]int {
+	days := make(map[time.Weekday]int, 7)
+	for _, session := range s.Conversations {
+		days[session.Start.Weekday()]++
+	}
+	return days
+}
+
+// ByHour returns how many conversations started in each hour of the day
+func (s Sessions) ByHour() [24]int {
+	var hours [24]int
+	for _, session := range s.Conversations {
+		hours[session.Start.Hour()]++
+	}
+	return hours
+}
+
+// WeekdayOrder returns the days of the week starting from WeekStart
+func (s Sessions) WeekdayOrder() []time.Weekday {
+	return weekdayOrder(s.WeekStart)
+}
+
+// Series counts the conversations that started in every step of r from the first conversation to the last
+func (s Sessions) Series(r Resolution) []SessionStep {
+	series := make([]SessionStep, 0)
+	if len(s.Conversations) == 0 {
+		return series
+	}
+
+	steps := make(map[int64]int)
+	for _, session := range s.Conversations {
+		steps[r.start(session.Start).Unix()]++
+	}
+
+	last := s.Conversations[len(s.Conversations)-1].Start
+	for step := r.start(s.Conversations[0].Start); !step.After(last); step = r.next(step) {
+		series = append(series, SessionStep{Label: r.label(step), Sessions: steps[step.Unix()]})
+	}
+	return series
+}
+
+// SessionStep is how many conversations started during one stretch of time
+type SessionStep struct {
+	Label    string `json:"label"`
+	Sessions int    `json:"sessions"`
+}
+
+// Report writes a short summary of the conversations in plain English
+func (s Sessions) Report(w io.Writer) {
+	if len(s.Conversations) == 0 {
+		return
+	}
+
+	days := len(s.Series(PerDay))
+	weeks := len(s.Series(PerWeek))
+	fmt.Fprintf(w, "There were %d conversations (with more than %s between them), %.1f a day or %.1f a week.\n",
+		len(s.Conversations), shortDuration(s.Gap), float64(len(s.Conversations))/float64(days), float64(len(s.Conversations))/float64(weeks))
+
+	length, messages := s.Average()
+	fmt.Fprintf(w, "Conversations go on for %s and %.1f messages on average.\n", shortDuration(length.Round(time.Second)), messages)
+
+	longest, _ := s.Longest()
+	fmt.Fprintf(w, "The longest conversation started on %s and went on for %s, %d messages all told!\n",
+		longest.Start.Format("Monday 2 January 2006 at 15:04"), shortDuration(longest.Length().Round(time.Second)), longest.Messages)
+
+	weekdays := s.ByWeekday()
+	busiest_day := s.WeekStart
+	for _, day := range s.WeekdayOrder() {
+		if weekdays[day] > weekdays[busiest_day] {
+			busiest_day = day
+		}
+	}
+	hours := s.ByHour()
+	busiest_hour := 0
+	for hour, sessions := range hours {
+		if sessions > hours[busiest_hour] {
+			busiest_hour = hour
+		}
+	}
+	fmt.Fprintf(w, "Most conversations start on %s (%d), and during the %dth hour of the day (%d).\n",
+		busiest_day, weekdays[busiest_day], busiest_hour, hours[busiest_hour])
+}
+
+// WriteCSV writes every conversation, one to a row
+func (s Sessions) WriteCSV(f io.Writer, opts CSVOptions) error {
+	w := newCSVWriter(f, opts)
+	w.writeStrings([]string{"Start", "End", "Minutes", "Messages", "Started by", "Last word"})
+	for _, session := range s.Conversations {
+		w.writeStrings([]string{
+			session.Start.Format(time.RFC3339),
+			session.End.Format(time.RFC3339),
+			strconv.Itoa(int(session.Length().Minutes())),
+			strconv.Itoa(session.Messages),
+			session.Starter,
+			session.Ender,
+		})
+	}
+	return w.close()
+}
+
+// WriteSeriesCSV writes how many conversations started in every step of r
+func (s Sessions) WriteSeriesCSV(f io.Writer, opts CSVOptions, r Resolution) error {
+	w := newCSVWriter(f, opts)
+	w.writeStrings([]string{r.heading(), "Sessions"})
+	for _, step := range s.Series(r) {
+		w.write(step.Label, step.Sessions)
+	}
+	return w.close()
+}
+
+// WriteWeekdayCSV writes how many conversations started on each day of the week, starting from WeekStart
+func (s Sessions) WriteWeekdayCSV(f io.Writer, opts CSVOptions) error {
+	w := newCSVWriter(f, opts)
+	w.writeStrings([]string{"Day", "Sessions"})
+	weekdays := s.ByWeekday()
+	for _, day := range s.WeekdayOrder() {
+		w.write(day.String(), weekdays[day])
+	}
+	return w.close()
+}
+
+// WriteHourlyCSV writes how many conversations started in each hour of the day
+func (s Sessions) WriteHourlyCSV(f io.Writer, opts CSVOptions) error {
+	w := newCSVWriter(f, opts)
+	w.writeStrings([]string{"Hour", "Sessions"})
+	for hour, sessions := range s.ByHour() {
+		w.write(fmt.Sprintf("%02d:00", hour), sessions)
+	}
+	return w.close()
+}
+
+// SessionSummary is everything Sessions found out, laid out to be marshalled to JSON
+type SessionSummary struct {
+	GapSeconds      float64       `json:"gap_seconds"`
+	Total           int           `json:"total"`
+	AverageMinutes  float64       `json:"average_minutes"`
+	AverageMessages float64       `json:"average_messages"`
+	Longest         *SessionInfo  `json:"longest,omitempty"`
+	ByWeekday       []SessionStep `json:"by_weekday"`
+	ByHour          []SessionStep `json:"by_hour"`
+	PerDay          []SessionStep `json:"per_day"`
+	PerWeek         []SessionStep `json:"per_week"`
+	Conversations   []SessionInfo `json:"conversations"`
+	// Users are how many conversations each user started and ended
+	Users []UserInitiative `json:"users"`
+}
+
+// SessionInfo is one conversation
+type SessionInfo struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Minutes  float64   `json:"minutes"`
+	Messages int       `json:"messages"`
+	Starter  string    `json:"starter"`
+	Ender    string    `json:"ender"`
+}
+
+func (session Session) info() SessionInfo {
+	return SessionInfo{
+		Start:    session.Start,
+		End:      session.End,
+		Minutes:  session.Length().Minutes(),
+		Messages: session.Messages,
+		Starter:  session.Starter,
+		Ender:    session.Ender,
+	}
+}
+
+// Summary gathers up the conversations, with every day of the week and hour of the day present
+func (s Sessions) Summary() SessionSummary {
+	length, messages := s.Average()
+	summary := SessionSummary{
+		GapSeconds:      s.Gap.Seconds(),
+		Total:           len(s.Conversations),
+		AverageMinutes:  length.Minutes(),
+		AverageMessages: messages,
+		ByWeekday:       make([]SessionStep, 0, 7),
+		ByHour:          make([]SessionStep, 0, 24),
+		PerDay:          s.Series(PerDay),
+		PerWeek:         s.Series(PerWeek),
+		Conversations:   make([]SessionInfo, 0, len(s.Conversations)),
+		Users:           s.initiative(),
+	}
+
+	if longest, present := s.Longest(); present {
+		info := longest.info()
+		summary.Longest = &info
+	}
+
+	weekdays := s.ByWeekday()
+	for _, day := range s.WeekdayOrder() {
+		summary.ByWeekday = append(summary.ByWeekday, SessionStep{Label: day.String(), Sessions: weekdays[day]})
+	}
+	for hour, sessions := range s.ByHour() {
+		summary.ByHour = append(summary.ByHour, SessionStep{Label: fmt.Sprintf("%02d:00", hour), Sessions: sessions})
+	}
+	for _, session := range s.Conversations {
+		summary.Conversations = append(summary.Conversations, session.info())
+	}
+
+	return summary
+}
@@ -0,0 +1,60 @@
+package stats
+
+import (
+	"sort"
+	"strings"
+)
+
+// stopWords are the everyday words in each language that would otherwise top everybody's list, by ISO 639-1 code
+var stopWords = map[string]string{
+	"de": `aber alle allem allen aller alles als also am an ander andere anderem anderen anderer anderes auch auf aus
+		bei bin bis bist da damit dann das dass dein deine dem den denn der des dich dir doch dort du durch ein eine
+		einem einen einer eines er es etwas euch euer eure für gegen hab habe haben hast hat hatte ich ihm ihn ihr
+		ihre im in ist ja jetzt kann kein keine man mein meine mich mir mit muss nach nein nicht nichts noch nun nur
+		ob oder ohne schon sehr sein seine sich sie sind so soll über um und uns unser unter viel vom von vor war
+		waren was weil wenn wer wie wieder will wir wird wo zu zum zur`,
+	"en": `a about above after again against all am an and any are aren't as at be because been before being below
+		between both but by can can't could couldn't did didn't do does doesn't doing don't down during each few for
+		from further had hadn't has hasn't have haven't having he he'd he'll he's her here here's hers herself him
+		himself his how how's i i'd i'll i'm i've if in into is isn't it it's its itself just let's me more most
+		mustn't my myself no nor not of off on once only or other ought our ours ourselves out over own same shan't
+		she she'd she'll she's should shouldn't so some such than that that's the their theirs them themselves then
+		there there's these they they'd they'll they're they've this those through to too under until up very was
+		wasn't we we'd we'll we're we've were weren't what what's when when's where where's which while who who's
+		whom why why's will with won't would wouldn't you you'd you'll you're you've your yours yourself yourselves`,
+	"es": `a al algo algunos ante antes como con contra cual cuando de del desde donde durante e el ella ellas ellos
+		en entre era es esa esas ese eso esos esta estaba estado estamos estar estas este esto estos estoy fue fueron
+		ha hay he la las le les lo los me mi mis mucho muy más nada ni no nos nosotros o os otra otro para pero poco
+		por porque que quien se sea ser si sin sobre son su sus también tambien te tengo tiene todo todos tu tus un
+		una uno unos vosotros y ya yo él`,
+	"fr": `a ai au aux avec avez avoir c ce ceci cela ces cet cette d dans de des donc du elle elles en est et été
+		eu il ils j je l la le les leur leurs lui m ma mais me même mes moi mon n ne nos notre nous on ou où par
+		pas pour qu que qui s sa se ses si son sont sur t ta te tes toi ton tu un une vos votre vous y à ça était`,
+	"it": `a ad al all alla alle anche avere c che chi ci col coll come con d da dal dall dalla dei del dell della
+		delle di e ed era è gli ha hai ho i il in io l la le lei lo loro lui m ma me mi mia mio ne nei nel nell nella
+		noi non o per perché più quando quell quella quello quest questa questo s se sei si sia sono su sua sull suo t
+		ti tra tu tua tuo un una uno vi voi`,
+	"nl": `aan al alles als bij dan dat de der deze die dit doch door dus een en er ge geen had heb hebben heeft
+		hem het hier hij hoe hun ik in is ja je kan kon maar me meer men met mij mijn na naar niet niets nog nu of
+		om omdat ons ook op over te tegen toch toen tot u uit van veel voor want was wat we wel werd wezen wie wij
+		wil worden zal ze zei zelf zich zij zijn zo zonder zou`,
+	"pt": `a ao aos as até com como da das de dela dele deles depois do dos e ela elas ele eles em entre era essa
+		esse esta este eu foi for há isso isto já lhe mais mas me mesmo meu minha muito na nas nem no nos nós não o
+		os ou para pela pelo por qual quando que quem se sem ser seu sua são só também te tem tu um uma você é`,
+}
+
+// StopWordLanguages returns the codes of every language there's a stop word list for, in alphabetical order
+func StopWordLanguages() []string {
+	languages := make([]string, 0, len(stopWords))
+	for language := range stopWords {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// StopWords returns the stop words for language, and whether there's a list for it at all
+func StopWords(language string) ([]string, bool) {
+	words, present := stopWords[strings.ToLower(language)]
+	return strings.Fields(words), present
+}
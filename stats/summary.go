@@ -0,0 +1,111 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+)
+
+// Summary is everything a Histogram found out, laid out to be marshalled to JSON
+type Summary struct {
+	TotalMessages int           `json:"total_messages"`
+	Users         []UserSummary `json:"users"`
+	Hours         []Bucket      `json:"hours"`
+	Weekdays      []Bucket      `json:"weekdays"`
+	AllTimeHourly []Bucket      `json:"all_time_hourly"`
+	AllTimeDaily  []Bucket      `json:"all_time_daily"`
+	// AllTimeWeekly is counted in ISO 8601 weeks, labelled eg. 2018-W05
+	AllTimeWeekly  []Bucket `json:"all_time_weekly"`
+	AllTimeMonthly []Bucket `json:"all_time_monthly"`
+	AllTimeYearly  []Bucket `json:"all_time_yearly"`
+}
+
+// UserSummary is how much one user had to say, and when they said most of it
+type UserSummary struct {
+	Name                  string `json:"name"`
+	Messages              int    `json:"messages"`
+	ChattiestDay          string `json:"chattiest_day"`
+	ChattiestDayMessages  int    `json:"chattiest_day_messages"`
+	ChattiestHour         int    `json:"chattiest_hour"`
+	ChattiestHourMessages int    `json:"chattiest_hour_messages"`
+	// Heatmap is the number of messages sent in every hour of each day of the week, starting from WeekStart
+	Heatmap []HeatmapDay `json:"heatmap"`
+}
+
+// HeatmapDay is the number of messages one user sent in each hour (from midnight) of one day of the week
+type HeatmapDay struct {
+	Day   string `json:"day"`
+	Hours []int  `json:"hours"`
+}
+
+// Bucket is the number of messages each user sent during one stretch of time
+type Bucket struct {
+	Label  string         `json:"label"`
+	Counts map[string]int `json:"counts"`
+	Total  int            `json:"total"`
+}
+
+func (h Histogram) bucket(label string, usernames []string, counts map[string]int) Bucket {
+	b := Bucket{Label: label, Counts: make(map[string]int, len(usernames))}
+	for _, user := range usernames {
+		b.Counts[user] = counts[user]
+		b.Total += counts[user]
+	}
+	return b
+}
+
+func (h Histogram) heatmapDays(user string) []HeatmapDay {
+	days := make([]HeatmapDay, 0, 7)
+	for _, day := range h.WeekdayOrder() {
+		hours := make([]int, 24)
+		for hour := range hours {
+			hours[hour] = h.ByWeekdayHour(day, hour, user)
+		}
+		days = append(days, HeatmapDay{Day: day.String(), Hours: hours})
+	}
+	return days
+}
+
+// Summary gathers up the histogram's results, with every bucket present and in order
+func (h Histogram) Summary() Summary {
+	usernames := h.UserNames()
+
+	s := Summary{
+		TotalMessages:  h.TotalMessages,
+		Users:          make([]UserSummary, 0, len(usernames)),
+		Hours:          make([]Bucket, 0, 24),
+		Weekdays:       make([]Bucket, 0, 7),
+		AllTimeHourly:  make([]Bucket, 0),
+		AllTimeDaily:   h.Series(PerDay),
+		AllTimeWeekly:  h.Series(PerWeek),
+		AllTimeMonthly: h.Series(PerMonth),
+		AllTimeYearly:  h.Series(PerYear),
+	}
+
+	day, daily_messages := h.ChattiestDay()
+	hour, hourly_messages := h.ChattiestHour()
+	for _, user := range usernames {
+		s.Users = append(s.Users, UserSummary{
+			Name:                  user,
+			Messages:              h.Users[user],
+			ChattiestDay:          day[user].String(),
+			ChattiestDayMessages:  daily_messages[user],
+			ChattiestHour:         hour[user],
+			ChattiestHourMessages: hourly_messages[user],
+			Heatmap:               h.heatmapDays(user),
+		})
+	}
+
+	for hour := 0; hour < 24; hour++ {
+		s.Hours = append(s.Hours, h.bucket(fmt.Sprintf("%02d:00", hour), usernames, h.Hours[hour]))
+	}
+
+	for _, day := range h.WeekdayOrder() {
+		s.Weekdays = append(s.Weekdays, h.bucket(day.String(), usernames, h.Weekdays[day]))
+	}
+
+	h.eachHour(func(hour time.Time, counts map[string]int) {
+		s.AllTimeHourly = append(s.AllTimeHourly, h.bucket(hour.Format(time.RFC3339), usernames, counts))
+	})
+
+	return s
+}
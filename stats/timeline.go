@@ -0,0 +1,69 @@
+package stats
+
+import (
+	"time"
+)
+
+// Timeline counts each user's messages in every hour from the first anybody sent to the last. Hours are kept in
+// order as a run of counts, so a chat that goes on for years costs a few ints an hour rather than a map entry for
+// every message.
+type Timeline struct {
+	// Start is the beginning of the first hour with a message in it, and End the beginning of the last
+	Start time.Time
+	End   time.Time
+	// Counts holds each user's count for every hour from Start, a user's counts stop after the last hour they sent
+	// anything in
+	Counts map[string][]int
+}
+
+// Hours returns how many hours there are from Start to End, including both
+func (t Timeline) Hours() int {
+	if t.Start.IsZero() {
+		return 0
+	}
+	return int(t.End.Sub(t.Start)/time.Hour) + 1
+}
+
+// Hour returns the beginning of the i'th hour from Start
+func (t Timeline) Hour(i int) time.Time {
+	return t.Start.Add(time.Duration(i) * time.Hour)
+}
+
+// Count returns how many messages user sent in the i'th hour from Start
+func (t Timeline) Count(i int, user string) int {
+	counts := t.Counts[user]
+	if i < 0 || i >= len(counts) {
+		return 0
+	}
+	return counts[i]
+}
+
+// add counts a message user sent during the hour beginning at hour. Hours are counted out from Start in elapsed time
+// rather than on the clock, so the two 1 o'clocks at the end of daylight saving time stay two separate hours.
+func (t *Timeline) add(hour time.Time, user string) {
+	if t.Counts == nil {
+		t.Counts = make(map[string][]int, 2)
+	}
+
+	switch {
+	case t.Start.IsZero():
+		t.Start, t.End = hour, hour
+	case hour.Before(t.Start):
+		// chat logs are very nearly always in order, so moving everything along is rare
+		shift := int(t.Start.Sub(hour) / time.Hour)
+		for u, counts := range t.Counts {
+			t.Counts[u] = append(make([]int, shift, shift+len(counts)), counts...)
+		}
+		t.Start = hour
+	case hour.After(t.End):
+		t.End = hour
+	}
+
+	i := int(hour.Sub(t.Start) / time.Hour)
+	counts := t.Counts[user]
+	if len(counts) <= i {
+		counts = append(counts, make([]int, i+1-len(counts))...)
+	}
+	counts[i]++
+	t.Counts[user] = counts
+}
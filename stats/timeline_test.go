@@ -0,0 +1,88 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimelineAdd(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skip("no time zone database: ", err)
+	}
+	base := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	hour := func(i int) time.Time { return base.Add(time.Duration(i) * time.Hour) }
+
+	type message struct {
+		hour time.Time
+		user string
+	}
+	cases := []struct {
+		name     string
+		messages []message
+		start    time.Time
+		hours    int
+		counts   map[string][]int
+	}{
+		{
+			name:     "in order",
+			messages: []message{{hour(0), "Anna"}, {hour(0), "Ben"}, {hour(2), "Anna"}},
+			start:    hour(0),
+			hours:    3,
+			counts:   map[string][]int{"Anna": {1, 0, 1}, "Ben": {1}},
+		},
+		{
+			name:     "an hour out of order",
+			messages: []message{{hour(2), "Anna"}, {hour(3), "Ben"}, {hour(0), "Anna"}},
+			start:    hour(0),
+			hours:    4,
+			counts:   map[string][]int{"Anna": {1, 0, 1}, "Ben": {0, 0, 0, 1}},
+		},
+		{
+			name:     "a new user out of order",
+			messages: []message{{hour(1), "Anna"}, {hour(0), "Ben"}},
+			start:    hour(0),
+			hours:    2,
+			counts:   map[string][]int{"Anna": {0, 1}, "Ben": {1}},
+		},
+		{
+			// midnight, then 1am BST and 1am GMT on the 28th of October 2018, the two 1 o'clocks are an hour apart
+			name: "the end of daylight saving time",
+			messages: []message{
+				{time.Date(2018, 10, 27, 23, 0, 0, 0, time.UTC).In(london), "Anna"},
+				{time.Date(2018, 10, 28, 0, 0, 0, 0, time.UTC).In(london), "Anna"},
+				{time.Date(2018, 10, 28, 1, 0, 0, 0, time.UTC).In(london), "Anna"},
+			},
+			start:  time.Date(2018, 10, 27, 23, 0, 0, 0, time.UTC),
+			hours:  3,
+			counts: map[string][]int{"Anna": {1, 1, 1}},
+		},
+	}
+
+	for _, c := range cases {
+		var timeline Timeline
+		for _, m := range c.messages {
+			timeline.add(m.hour, m.user)
+		}
+		if !timeline.Start.Equal(c.start) {
+			t.Errorf("%s: starts at %s, expected %s", c.name, timeline.Start, c.start)
+		}
+		if timeline.Hours() != c.hours {
+			t.Errorf("%s: %d hours, expected %d", c.name, timeline.Hours(), c.hours)
+		}
+		if !reflect.DeepEqual(timeline.Counts, c.counts) {
+			t.Errorf("%s: counts %v, expected %v", c.name, timeline.Counts, c.counts)
+		}
+	}
+}
+
+func TestTimelineEmpty(t *testing.T) {
+	var timeline Timeline
+	if timeline.Hours() != 0 {
+		t.Errorf("an empty timeline has %d hours", timeline.Hours())
+	}
+	if timeline.Count(0, "Anna") != 0 {
+		t.Errorf("an empty timeline has %d messages from Anna", timeline.Count(0, "Anna"))
+	}
+}
@@ -0,0 +1,362 @@
+package stats
+
+import (
+	"fmt"
+	"github.com/rsalmond/kissyface/chat"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// WordCounts counts the words everybody uses
+type WordCounts struct {
+	// StopWords are left out of everyone's top and distinctive words, though they still count towards their vocabulary
+	StopWords map[string]bool
+	// Counts are how many times each user used each word, in lower case
+	Counts map[string]map[string]int
+}
+
+// a word has to be used at least this often by somebody before it can be called distinctive of them
+const distinctiveMinimum = 3
+
+// NewWordCounts prepares to count words, leaving stopWords out of the interesting ones
+func NewWordCounts(stopWords []string) *WordCounts {
+	c := new(WordCounts)
+	c.StopWords = make(map[string]bool, len(stopWords))
+	for _, word := range stopWords {
+		c.StopWords[strings.ToLower(word)] = true
+	}
+	c.Counts = make(map[string]map[string]int, 2)
+	return c
+}
+
+// Feed counts the words in a single message
+func (c *WordCounts) Feed(m chat.Message) {
+	if _, present := c.Counts[m.User]; !present {
+		c.Counts[m.User] = make(map[string]int)
+	}
+	for _, word := range Tokenize(m.Body) {
+		c.Counts[m.User][word]++
+	}
+}
+
+func isWordRune(r rune) bool {
+	// marks are the accents and vowel signs that combine with the letter before them in lots of scripts
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+func isApostrophe(r rune) bool {
+	return r == '\'' || r == '’'
+}
+
+// French and Italian elide a short word into the next one when it starts with a vowel (l'amour, j'ai, qu'il,
+// dell'anno). These are the short words, which are words in their own right and usually stop words too.
+var elisions = map[string]bool{
+	"c": true, "d": true, "j": true, "l": true, "m": true, "n": true, "s": true, "t": true,
+	"qu": true, "jusqu": true, "lorsqu": true, "puisqu": true, "quoiqu": true,
+	"all": true, "dall": true, "dell": true, "nell": true, "sull": true, "coll": true,
+	"un": true, "quest": true, "quell": true,
+}
+
+// elided splits an elided word off the front of word, eg. l'amour becomes l and amour. English contractions like
+// don't and all's are left alone, the words they're made of don't elide or don't come before a vowel.
+func elided(word string) (string, string, bool) {
+	i := strings.IndexRune(word, '\'')
+	if i < 0 || !elisions[word[:i]] {
+		return "", word, false
+	}
+	next := []rune(word[i+1:])
+	if !strings.ContainsRune("aeiouyhàâäæéèêëîïìíòóôöœùúûü", next[0]) {
+		return "", word, false
+	}
+	return word[:i], word[i+1:], true
+}
+
+// Tokenize splits text into lower case words. Punctuation and emoji separate words, apostrophes inside them (as in
+// don't) are kept unless they're French or Italian elisions (as in l'amour), and links and plain numbers are left out
+// altogether.
+func Tokenize(text string) []string {
+	words := make([]string, 0)
+	for _, field := range strings.Fields(text) {
+		if strings.Contains(field, "://") || strings.HasPrefix(strings.ToLower(field), "www.") {
+			continue
+		}
+
+		runes := []rune(strings.ToLower(field))
+		start := -1
+		flush := func(end int) {
+			word := string(runes[start:end])
+			if short, rest, ok := elided(word); ok {
+				words = append(words, short)
+				word = rest
+			}
+			if strings.IndexFunc(word, unicode.IsLetter) >= 0 {
+				words = append(words, word)
+			}
+			start = -1
+		}
+
+		for i, r := range runes {
+			switch {
+			case isWordRune(r):
+				if start < 0 {
+					start = i
+				}
+			case isApostrophe(r) && start >= 0 && i+1 < len(runes) && isWordRune(runes[i+1]):
+				// curly and straight apostrophes are the same thing
+				runes[i] = '\''
+			case start >= 0:
+				flush(i)
+			}
+		}
+		if start >= 0 {
+			flush(len(runes))
+		}
+	}
+	return words
+}
+
+// UserNames returns everyone who sent a message, in alphabetical order
+func (c WordCounts) UserNames() []string {
+	usernames := make([]string, 0, len(c.Counts))
+	for user := range c.Counts {
+		usernames = append(usernames, user)
+	}
+	sort.Strings(usernames)
+	return usernames
+}
+
+// Total returns how many words user wrote
+func (c WordCounts) Total(user string) int {
+	total := 0
+	for _, count := range c.Counts[user] {
+		total += count
+	}
+	return total
+}
+
+// Vocabulary returns how many different words user wrote
+func (c WordCounts) Vocabulary(user string) int {
+	return len(c.Counts[user])
+}
+
+// TypeTokenRatio returns the share of user's words that are different from each other, the higher the more varied
+// their vocabulary. It falls the more somebody writes, so it's only fair to compare people who wrote about as much.
+func (c WordCounts) TypeTokenRatio(user string) float64 {
+	total := c.Total(user)
+	if total == 0 {
+		return 0
+	}
+	return float64(c.Vocabulary(user)) / float64(total)
+}
+
+// WordCount is how many times a word was used
+type WordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// Top returns the n words user used most, stop words aside. Ties are in alphabetical order.
+func (c WordCounts) Top(user string, n int) []WordCount {
+	top := make([]WordCount, 0, len(c.Counts[user]))
+	for word, count := range c.Counts[user] {
+		if !c.StopWords[word] {
+			top = append(top, WordCount{Word: word, Count: count})
+		}
+	}
+	sortWordCounts(top)
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+func sortWordCounts(counts []WordCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Word < counts[j].Word
+	})
+}
+
+// everyone adds up everybody's counts of each word, stop words aside, and returns them along with the grand total
+func (c WordCounts) everyone() (map[string]int, int) {
+	counts := make(map[string]int)
+	total := 0
+	for _, words := range c.Counts {
+		for word, count := range words {
+			if !c.StopWords[word] {
+				counts[word] += count
+				total += count
+			}
+		}
+	}
+	return counts, total
+}
+
+// WordScore is how strongly a word is associated with a user
+type WordScore struct {
+	Word  string  `json:"word"`
+	Count int     `json:"count"`
+	Score float64 `json:"score"`
+}
+
+// Distinctive returns the n words that most set user apart from everyone else. They're scored with the log-odds
+// ratio (with an informative Dirichlet prior, as in Monroe, Colaresi and Quinn's "Fightin' Words"), which doesn't let
+// a word someone used once or twice crowd out the ones they really do say all the time.
+func (c WordCounts) Distinctive(user string, n int) []WordScore {
+	scores := make([]WordScore, 0)
+	if len(c.Counts) < 2 {
+		return scores
+	}
+
+	everyone, total := c.everyone()
+	mine := 0
+	for word, count := range c.Counts[user] {
+		if !c.StopWords[word] {
+			mine += count
+		}
+	}
+	theirs := total - mine
+
+	for word, count := range c.Counts[user] {
+		if c.StopWords[word] || count < distinctiveMinimum {
+			continue
+		}
+
+		// the prior is everyone's use of the word, as if they'd said it all over again
+		prior := float64(everyone[word])
+		prior_total := float64(total)
+		mine_rest := float64(mine) + prior_total - float64(count) - prior
+		theirs_rest := float64(theirs) + prior_total - float64(everyone[word]-count) - prior
+		if mine_rest <= 0 || theirs_rest <= 0 {
+			continue
+		}
+
+		delta := math.Log((float64(count)+prior)/mine_rest) - math.Log((float64(everyone[word]-count)+prior)/theirs_rest)
+		variance := 1/(float64(count)+prior) + 1/(float64(everyone[word]-count)+prior)
+		score := delta / math.Sqrt(variance)
+		if score > 0 {
+			scores = append(scores, WordScore{Word: word, Count: count, Score: score})
+		}
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Word < scores[j].Word
+	})
+	if len(scores) > n {
+		scores = scores[:n]
+	}
+	return scores
+}
+
+// Report writes everyone's vocabulary and favourite words in plain English, listing n of them at most
+func (c WordCounts) Report(w io.Writer, n int) {
+	for _, user := range c.UserNames() {
+		if c.Total(user) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s wrote %d words, %d of them different (a type/token ratio of %.2f).\n",
+			user, c.Total(user), c.Vocabulary(user), c.TypeTokenRatio(user))
+
+		top := make([]string, 0, n)
+		for _, word := range c.Top(user, n) {
+			top = append(top, fmt.Sprintf("%s (%d)", word.Word, word.Count))
+		}
+		if len(top) > 0 {
+			fmt.Fprintf(w, "%s's favourite words are %s.\n", user, strings.Join(top, ", "))
+		}
+
+		distinctive := make([]string, 0, n)
+		for _, word := range c.Distinctive(user, n) {
+			distinctive = append(distinctive, word.Word)
+		}
+		if len(distinctive) > 0 {
+			fmt.Fprintf(w, "The words that set %s apart are %s.\n", user, strings.Join(distinctive, ", "))
+		}
+	}
+}
+
+// WriteCSV writes how many times each user used every word, stop words aside, the most used first
+func (c WordCounts) WriteCSV(f io.Writer, opts CSVOptions) error {
+	usernames := c.UserNames()
+	everyone, _ := c.everyone()
+
+	words := make([]WordCount, 0, len(everyone))
+	for word, count := range everyone {
+		words = append(words, WordCount{Word: word, Count: count})
+	}
+	sortWordCounts(words)
+
+	w := newCSVWriter(f, opts)
+	header := append([]string{"Word"}, usernames...)
+	w.writeStrings(append(header, "Total"))
+	for _, word := range words {
+		row := make([]int, 0, len(usernames)+1)
+		for _, user := range usernames {
+			row = append(row, c.Counts[user][word.Word])
+		}
+		w.write(word.Word, append(row, word.Count)...)
+	}
+	return w.close()
+}
+
+// WriteVocabularyCSV writes how many words, and how many different words, each user wrote
+func (c WordCounts) WriteVocabularyCSV(f io.Writer, opts CSVOptions) error {
+	w := newCSVWriter(f, opts)
+	w.writeStrings([]string{"User", "Words", "Vocabulary", "Type/token ratio"})
+	for _, user := range c.UserNames() {
+		w.writeStrings([]string{
+			user,
+			strconv.Itoa(c.Total(user)),
+			strconv.Itoa(c.Vocabulary(user)),
+			strconv.FormatFloat(c.TypeTokenRatio(user), 'f', 4, 64),
+		})
+	}
+	return w.close()
+}
+
+// WriteDistinctiveCSV writes the n words that most set each user apart, with their scores
+func (c WordCounts) WriteDistinctiveCSV(f io.Writer, opts CSVOptions, n int) error {
+	w := newCSVWriter(f, opts)
+	w.writeStrings([]string{"User", "Word", "Count", "Score"})
+	for _, user := range c.UserNames() {
+		for _, word := range c.Distinctive(user, n) {
+			w.writeStrings([]string{user, word.Word, strconv.Itoa(word.Count), strconv.FormatFloat(word.Score, 'f', 2, 64)})
+		}
+	}
+	return w.close()
+}
+
+// UserWords is one user's vocabulary and the n words they use most, and that most set them apart
+type UserWords struct {
+	Name           string      `json:"name"`
+	Words          int         `json:"words"`
+	Vocabulary     int         `json:"vocabulary"`
+	TypeTokenRatio float64     `json:"type_token_ratio"`
+	Top            []WordCount `json:"top"`
+	Distinctive    []WordScore `json:"distinctive"`
+}
+
+// Summary gathers up everyone's vocabulary, listing n words at most for each of them
+func (c WordCounts) Summary(n int) []UserWords {
+	users := make([]UserWords, 0, len(c.Counts))
+	for _, user := range c.UserNames() {
+		users = append(users, UserWords{
+			Name:           user,
+			Words:          c.Total(user),
+			Vocabulary:     c.Vocabulary(user),
+			TypeTokenRatio: c.TypeTokenRatio(user),
+			Top:            c.Top(user, n),
+			Distinctive:    c.Distinctive(user, n),
+		})
+	}
+	return users
+}
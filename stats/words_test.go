@@ -0,0 +1,55 @@
+package stats
+
+import (
+	"github.com/rsalmond/kissyface/chat"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	cases := []struct {
+		text  string
+		words []string
+	}{
+		{"Hello, World!", []string{"hello", "world"}},
+		{"don't", []string{"don't"}},
+		{"don’t stop", []string{"don't", "stop"}},
+		{"'quoted' words'", []string{"quoted", "words"}},
+		{"rock 'n' roll", []string{"rock", "n", "roll"}},
+		{"see https://example.com/a-b and www.example.com", []string{"see", "and"}},
+		{"at 10 or 10pm", []string{"at", "or", "10pm"}},
+		{"love😍you", []string{"love", "you"}},
+		{"café naïve", []string{"café", "naïve"}},
+		{"cafe\u0301 with a combining accent", []string{"cafe\u0301", "with", "a", "combining", "accent"}},
+		{"привет мир", []string{"привет", "мир"}},
+		{"well-known", []string{"well", "known"}},
+		{"c'est l'amour, j'ai dit qu’il", []string{"c", "est", "l", "amour", "j", "ai", "dit", "qu", "il"}},
+		{"jusqu'à aujourd'hui", []string{"jusqu", "à", "aujourd'hui"}},
+		{"dell'anno all'inizio", []string{"dell", "anno", "all", "inizio"}},
+		{"all's well, c'mon", []string{"all's", "well", "c'mon"}},
+		{"", []string{}},
+	}
+
+	for _, c := range cases {
+		if words := Tokenize(c.text); !reflect.DeepEqual(words, c.words) {
+			t.Errorf("Tokenize(%q) = %q, expected %q", c.text, words, c.words)
+		}
+	}
+}
+
+func TestTopFrench(t *testing.T) {
+	stop_words, _ := StopWords("fr")
+	c := NewWordCounts(stop_words)
+	for _, body := range []string{
+		"C'est l'amour, j'ai dit qu'il m'aime",
+		"L'amour n'est pas ce qu'on croit, c'est l'amitié",
+		"J'espère qu'elle s'en souvient",
+	} {
+		c.Feed(chat.Message{User: "Anna", Body: body})
+	}
+
+	expected := []WordCount{{"amour", 2}, {"aime", 1}, {"amitié", 1}, {"croit", 1}, {"dit", 1}}
+	if top := c.Top("Anna", 5); !reflect.DeepEqual(top, expected) {
+		t.Errorf("Top = %v, expected %v", top, expected)
+	}
+}